@@ -39,6 +39,13 @@ func buildAgentSDKSettingsOverrides(appCfg *config.Config, workspaceDir string,
 		cfg = extensions.MergeAgentsConfig(pluginCfg, cfg)
 	}
 
+	// MCP server tools are not covered by the mutating-tool classification,
+	// so read-only mode does not start them at all.
+	if appCfg != nil && appCfg.ReadOnly.Enabled && cfg != nil && len(cfg.MCPServers) > 0 {
+		warnings = append(warnings, fmt.Sprintf("read-only mode: %d MCP server(s) disabled", len(cfg.MCPServers)))
+		cfg = nil
+	}
+
 	overrides := &sdkconfig.Settings{}
 
 	if ShouldPersistAgentSDKHistory(appCfg) {
@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	sdkapi "github.com/cexll/agentsdk-go/pkg/api"
+	sdkmodel "github.com/cexll/agentsdk-go/pkg/model"
+	agenttools "github.com/smallnest/goclaw/agent/tools"
+)
+
+// sdkToolTurn is the tool state of one Run/RunStream call.
+//
+// agentsdk executes the tool calls of a model response one after another, so
+// when a response arrives the turn starts its leading read-only calls as one
+// batch (see prefetch); the adapters then return those results as agentsdk
+// reaches each call. Every call of the run goes through one dispatcher, so
+// max_parallel and the run-scoped serial keys apply per run, not per agent.
+type sdkToolTurn struct {
+	dispatcher *agenttools.Dispatcher
+	workspace  string
+	tools      map[string]agenttools.Tool
+	ctx        context.Context
+	cancel     context.CancelFunc
+
+	mu      sync.Mutex
+	started map[string][]*agenttools.PendingCall
+}
+
+type sdkToolTurnKey struct{}
+
+// newSDKToolTurn creates the turn of a run. Calls started early run under
+// ctx, so they keep the run's values and stop with it; whitelist limits them
+// to the tools the request allows.
+func newSDKToolTurn(ctx context.Context, existing []agenttools.Tool, opts agenttools.BatchOptions, whitelist []string) *sdkToolTurn {
+	allowed := make(map[string]bool, len(whitelist))
+	for _, name := range whitelist {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed[name] = true
+		}
+	}
+	byName := make(map[string]agenttools.Tool, len(existing))
+	for _, t := range existing {
+		if t == nil || (len(allowed) > 0 && !allowed[t.Name()]) {
+			continue
+		}
+		byName[t.Name()] = t
+	}
+
+	turnCtx, cancel := context.WithCancel(ctx)
+	return &sdkToolTurn{
+		dispatcher: agenttools.NewDispatcher(opts),
+		workspace:  opts.Workspace,
+		tools:      byName,
+		ctx:        turnCtx,
+		cancel:     cancel,
+		started:    make(map[string][]*agenttools.PendingCall),
+	}
+}
+
+func withSDKToolTurn(ctx context.Context, turn *sdkToolTurn) context.Context {
+	return context.WithValue(ctx, sdkToolTurnKey{}, turn)
+}
+
+func sdkToolTurnFrom(ctx context.Context) *sdkToolTurn {
+	turn, _ := ctx.Value(sdkToolTurnKey{}).(*sdkToolTurn)
+	return turn
+}
+
+// Close stops the calls started early that agentsdk never asked for.
+func (t *sdkToolTurn) Close() {
+	t.cancel()
+}
+
+// prefetch starts the calls of a model response that can safely run before
+// agentsdk asks for them: the leading calls that are read-only and have no
+// serial key. It stops at the first other call, so nothing is read ahead of
+// a write the model ordered before it, and a call agentsdk's hooks or
+// permissions later reject has no side effects; its result is dropped.
+func (t *sdkToolTurn) prefetch(calls []agenttools.ToolCall) {
+	var batch []agenttools.ToolCall
+	var keys []string
+	for _, call := range calls {
+		tool, ok := t.tools[call.Name]
+		if !ok || agenttools.IsMutating(tool) || agenttools.SerialKey(tool, call.Params, t.workspace) != "" {
+			break
+		}
+		key, ok := toolCallKey(call.Name, call.Params)
+		if !ok {
+			break
+		}
+		batch = append(batch, call)
+		keys = append(keys, key)
+	}
+	// A lone call gains nothing from starting early.
+	if len(batch) < 2 {
+		return
+	}
+
+	pending := t.dispatcher.Start(t.ctx, batch, func(name string) (agenttools.Tool, bool) {
+		tool, ok := t.tools[name]
+		return tool, ok
+	})
+	t.mu.Lock()
+	for i, key := range keys {
+		t.started[key] = append(t.started[key], pending[i])
+	}
+	t.mu.Unlock()
+}
+
+// Execute returns the result of a matching call started by prefetch, or runs
+// the call through the turn's dispatcher.
+func (t *sdkToolTurn) Execute(ctx context.Context, tool agenttools.Tool, params map[string]interface{}) (string, error) {
+	if p := t.take(tool.Name(), params); p != nil {
+		select {
+		case <-p.Done():
+			result := p.Result()
+			return result.Output, result.Err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return t.dispatcher.Execute(ctx, tool, params)
+}
+
+func (t *sdkToolTurn) take(name string, params map[string]interface{}) *agenttools.PendingCall {
+	key, ok := toolCallKey(name, params)
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	queue := t.started[key]
+	if len(queue) == 0 {
+		return nil
+	}
+	if len(queue) == 1 {
+		delete(t.started, key)
+	} else {
+		t.started[key] = queue[1:]
+	}
+	return queue[0]
+}
+
+// toolCallKey identifies a call by tool name and arguments; json.Marshal
+// sorts map keys, so equal arguments give equal keys.
+func toolCallKey(name string, params map[string]interface{}) (string, bool) {
+	if len(params) == 0 {
+		return name + "\x00{}", true
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", false
+	}
+	return name + "\x00" + string(data), true
+}
+
+// toolPrefetchModelFactory wraps the models of a runtime so the tool calls of
+// each response are handed to the run's sdkToolTurn as soon as it arrives.
+type toolPrefetchModelFactory struct {
+	inner sdkapi.ModelFactory
+}
+
+func (f toolPrefetchModelFactory) Model(ctx context.Context) (sdkmodel.Model, error) {
+	model, err := f.inner.Model(ctx)
+	if err != nil || model == nil {
+		return model, err
+	}
+	return &toolPrefetchModel{Model: model}, nil
+}
+
+type toolPrefetchModel struct {
+	sdkmodel.Model
+}
+
+func (m *toolPrefetchModel) Complete(ctx context.Context, req sdkmodel.Request) (*sdkmodel.Response, error) {
+	resp, err := m.Model.Complete(ctx, req)
+	if err == nil && resp != nil {
+		prefetchToolCalls(ctx, resp.Message.ToolCalls)
+	}
+	return resp, err
+}
+
+func (m *toolPrefetchModel) CompleteStream(ctx context.Context, req sdkmodel.Request, cb sdkmodel.StreamHandler) error {
+	return m.Model.CompleteStream(ctx, req, func(res sdkmodel.StreamResult) error {
+		if res.Final && res.Response != nil {
+			prefetchToolCalls(ctx, res.Response.Message.ToolCalls)
+		}
+		return cb(res)
+	})
+}
+
+func prefetchToolCalls(ctx context.Context, calls []sdkmodel.ToolCall) {
+	turn := sdkToolTurnFrom(ctx)
+	if turn == nil || len(calls) < 2 {
+		return
+	}
+	converted := make([]agenttools.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		converted = append(converted, agenttools.ToolCall{ID: call.ID, Name: call.Name, Params: call.Arguments})
+	}
+	turn.prefetch(converted)
+}
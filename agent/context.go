@@ -30,6 +30,7 @@ type ContextBuilder struct {
 	memory    *MemoryStore
 	workspace string
 	tools     *ToolRegistry
+	pins      *PinStore
 }
 
 // NewContextBuilder 创建上下文构建器
@@ -37,9 +38,31 @@ func NewContextBuilder(memory *MemoryStore, workspace string) *ContextBuilder {
 	return &ContextBuilder{
 		memory:    memory,
 		workspace: workspace,
+		pins:      NewPinStore(workspace),
 	}
 }
 
+// Pins returns the per-chat pinned note store.
+func (b *ContextBuilder) Pins() *PinStore {
+	if b == nil {
+		return nil
+	}
+	return b.pins
+}
+
+// BuildPinnedContext renders the user-pinned facts of chatKey, or "" if there are none.
+func (b *ContextBuilder) BuildPinnedContext(chatKey string) string {
+	if b == nil || b.pins == nil || strings.TrimSpace(chatKey) == "" {
+		return ""
+	}
+	pins, err := b.pins.List(chatKey)
+	if err != nil {
+		logger.Warn("Failed to load pinned notes", zap.String("chat_key", chatKey), zap.Error(err))
+		return ""
+	}
+	return FormatPinnedContext(pins)
+}
+
 // SetToolRegistry sets the runtime tool registry used to render dynamic tool hints.
 func (b *ContextBuilder) SetToolRegistry(registry *ToolRegistry) {
 	b.tools = registry
@@ -210,3 +210,49 @@ func sanitizePathComponent(value string) string {
 	}
 	return sanitized
 }
+
+// migrateAgentSDKHistory moves the agentsdk history of sessionKey saved before
+// session key components were escaped, under the raw key, to the file of
+// sessionKey. Sanitized file names can collide, so the old file is only taken
+// when it records the raw key as its session ID.
+func migrateAgentSDKHistory(sessionKey, workspace string) {
+	sessionKey = strings.TrimSpace(sessionKey)
+	legacyKey := LegacySessionKey(sessionKey)
+	if legacyKey == sessionKey {
+		return
+	}
+	path := agentSDKHistoryPath(sessionKey, workspace)
+	legacyPath := agentSDKHistoryPath(legacyKey, workspace)
+	if path == "" || path == legacyPath {
+		return
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return
+	}
+	data, err := os.ReadFile(legacyPath)
+	if err != nil {
+		return
+	}
+
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return
+	}
+	var storedID string
+	if err := json.Unmarshal(wrapper["session_id"], &storedID); err != nil || storedID != legacyKey {
+		return
+	}
+	wrapper["session_id"], _ = json.Marshal(sessionKey)
+	migrated, err := json.Marshal(wrapper)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(path, migrated, 0644); err != nil {
+		logger.Warn("Failed to migrate agentsdk history",
+			zap.String("session", sessionKey),
+			zap.String("workspace", workspace),
+			zap.Error(err))
+		return
+	}
+	_ = os.Remove(legacyPath)
+}
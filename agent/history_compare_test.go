@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateAgentSDKHistoryMovesHistoryOfUnescapedKey(t *testing.T) {
+	workspace := t.TempDir()
+	key := SessionRef{Channel: "telegram", AccountID: "bot", ChatID: "group:42"}.String()
+	legacyPath := agentSDKHistoryPath("telegram:bot:group:42", workspace)
+	if err := os.MkdirAll(filepath.Dir(legacyPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := `{"version":1,"session_id":"telegram:bot:group:42","messages":[{"role":"user","content":"hi"}]}`
+	if err := os.WriteFile(legacyPath, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	migrateAgentSDKHistory(key, workspace)
+
+	data, err := os.ReadFile(agentSDKHistoryPath(key, workspace))
+	if err != nil {
+		t.Fatalf("history not moved to the escaped key: %v", err)
+	}
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := json.Marshal(key); string(got["session_id"]) != string(id) {
+		t.Fatalf("session_id = %s, want %s", got["session_id"], id)
+	}
+	if string(got["messages"]) != `[{"role":"user","content":"hi"}]` {
+		t.Fatalf("messages changed: %s", got["messages"])
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Fatalf("legacy history left behind: %v", err)
+	}
+}
+
+func TestMigrateAgentSDKHistoryKeepsHistoryOfAnotherSession(t *testing.T) {
+	workspace := t.TempDir()
+	key := SessionRef{Channel: "telegram", AccountID: "bot", ChatID: "group:42"}.String()
+	// "telegram:bot:group-42" 与旧 key 清洗后的文件名相同
+	legacyPath := agentSDKHistoryPath("telegram:bot:group-42", workspace)
+	if err := os.MkdirAll(filepath.Dir(legacyPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	other := `{"version":1,"session_id":"telegram:bot:group-42","messages":[]}`
+	if err := os.WriteFile(legacyPath, []byte(other), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	migrateAgentSDKHistory(key, workspace)
+
+	if _, err := os.Stat(agentSDKHistoryPath(key, workspace)); !os.IsNotExist(err) {
+		t.Fatalf("history of another session was migrated: %v", err)
+	}
+	if data, _ := os.ReadFile(legacyPath); string(data) != other {
+		t.Fatalf("history of another session changed: %s", data)
+	}
+}
@@ -19,6 +19,11 @@ type MainRunRequest struct {
 	Workspace    string
 	Metadata     map[string]any
 	Media        []MainRunMedia
+	// PinnedContext carries per-chat context (the user's pinned facts). The
+	// system prompt is shared by every session of a runtime, so the runtime
+	// appends it to the system prompt of each model call of this run instead;
+	// it is never stored in the session history.
+	PinnedContext string
 	// ToolWhitelist restricts which tools are exposed to the model for this
 	// request. Nil means "no restriction" (default behaviour). Note that in
 	// agentsdk-go an empty slice is treated the same as nil, so to effectively
@@ -50,6 +50,9 @@ type sdkRuntimeEntry struct {
 	maxTokens   int
 	inUse       int
 	invalidated bool
+	// tools and toolOpts build the sdkToolTurn of each run
+	tools    []agenttools.Tool
+	toolOpts agenttools.BatchOptions
 }
 
 // NewAgentSDKMainRuntime creates a main runtime backed by agentsdk-go.
@@ -93,8 +96,11 @@ func (r *AgentSDKMainRuntime) Run(ctx context.Context, req MainRunRequest) (*Mai
 		ToolWhitelist: append([]string(nil), req.ToolWhitelist...),
 	}
 	request.ContentBlocks = buildContentBlocks(req.Prompt, req.Media)
+	migrateAgentSDKHistory(request.SessionID, entry.workspace)
 
-	resp, err := runtime.Run(ctx, request)
+	turn := entry.newToolTurn(ctx, req.ToolWhitelist)
+	defer turn.Close()
+	resp, err := runtime.Run(withPinnedContext(withSDKToolTurn(ctx, turn), req.PinnedContext), request)
 	if err != nil {
 		return nil, err
 	}
@@ -130,9 +136,12 @@ func (r *AgentSDKMainRuntime) RunStream(ctx context.Context, req MainRunRequest)
 		ToolWhitelist: append([]string(nil), req.ToolWhitelist...),
 	}
 	request.ContentBlocks = buildContentBlocks(req.Prompt, req.Media)
+	migrateAgentSDKHistory(request.SessionID, entry.workspace)
 
-	stream, err := runtime.RunStream(ctx, request)
+	turn := entry.newToolTurn(ctx, req.ToolWhitelist)
+	stream, err := runtime.RunStream(withPinnedContext(withSDKToolTurn(ctx, turn), req.PinnedContext), request)
 	if err != nil {
+		turn.Close()
 		r.releaseRuntime(agentID, entry)
 		return nil, err
 	}
@@ -141,6 +150,7 @@ func (r *AgentSDKMainRuntime) RunStream(ctx context.Context, req MainRunRequest)
 	go func() {
 		defer close(out)
 		defer r.releaseRuntime(agentID, entry)
+		defer turn.Close()
 		for evt := range stream {
 			out <- evt
 		}
@@ -277,8 +287,12 @@ func (r *AgentSDKMainRuntime) getOrCreateRuntimeEntry(ctx context.Context, req M
 
 	// Load workspace extensions (skills + MCP config) at runtime creation time.
 	// Builtin skills are incrementally synced into workspace/.agents/skills.
+	// Read-only mode skips the sync so the workspace is never written.
 	mainSkillsDir := extensions.AgentsSkillsDir(workspace)
-	if err := internal.EnsureBuiltinSkillsForWorkspace(workspace); err != nil {
+	readOnly := r.cfg.ReadOnly.Enabled
+	if readOnly {
+		logger.Info("Read-only mode: skipping builtin skills sync", zap.String("workspace", workspace))
+	} else if err := internal.EnsureBuiltinSkillsForWorkspace(workspace); err != nil {
 		logger.Warn("Failed to sync builtin skills into workspace",
 			zap.String("agent_id", agentID),
 			zap.String("workspace", workspace),
@@ -300,6 +314,13 @@ func (r *AgentSDKMainRuntime) getOrCreateRuntimeEntry(ctx context.Context, req M
 	}, append(pluginResult.SkillDirs, mainSkillsDir)...))
 
 	mergedHooks := append([]corehooks.ShellHook{}, pluginResult.Hooks...)
+	if readOnly && len(mergedHooks) > 0 {
+		// Plugin hooks run shell commands, which read-only mode forbids.
+		logger.Warn("Read-only mode: dropping plugin shell hooks",
+			zap.String("agent_id", agentID),
+			zap.Int("hooks", len(mergedHooks)))
+		mergedHooks = nil
+	}
 	mergedCommands := mergeCommandRegistrations(pluginResult.Commands, nil)
 	mergedSubagents := mergeSubagentRegistrations(pluginResult.Subagents, nil)
 
@@ -311,15 +332,16 @@ func (r *AgentSDKMainRuntime) getOrCreateRuntimeEntry(ctx context.Context, req M
 			zap.String("warning", w))
 	}
 
+	existingTools := r.tools.ListExisting()
 	opts := sdkapi.Options{
 		ProjectRoot:   workspace,
-		ModelFactory:  modelFactory,
+		ModelFactory:  pinnedContextModelFactory{inner: toolPrefetchModelFactory{inner: modelFactory}},
 		SystemPrompt:  systemPrompt,
 		MaxIterations: maxIterations,
 		MaxSessions:   1000,
 		Timeout:       runtimeTimeout,
 		TaskStore:     r.taskStore,
-		Tools:         buildAgentSDKTools(r.tools.ListExisting()),
+		Tools:         buildAgentSDKTools(existingTools, toolBatchOptions(r.cfg, workspace)),
 		SkillDirs:     skillDirs,
 		// GoClaw now explicitly controls skill directories and lets agentsdk load skills dynamically.
 		DisableDefaultProjectSkills: true,
@@ -345,6 +367,8 @@ func (r *AgentSDKMainRuntime) getOrCreateRuntimeEntry(ctx context.Context, req M
 		temperature: temperature,
 		maxTokens:   maxTokens,
 		inUse:       1,
+		tools:       existingTools,
+		toolOpts:    toolBatchOptions(r.cfg, workspace),
 	}
 
 	r.mu.Lock()
@@ -436,17 +460,88 @@ func buildAgentSDKModelFactory(cfg *config.Config, modelName string, maxTokens i
 	}
 }
 
-func buildAgentSDKTools(existing []agenttools.Tool) []sdktool.Tool {
+type pinnedContextKey struct{}
+
+// withPinnedContext attaches the pinned context of a run to ctx.
+func withPinnedContext(ctx context.Context, pinned string) context.Context {
+	if strings.TrimSpace(pinned) == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, pinnedContextKey{}, pinned)
+}
+
+// pinnedContextModelFactory wraps the models of a runtime so every model call
+// of a run carries the run's pinned context in its system prompt. The block
+// is added per call and never enters the session history, so it is current
+// after pins change and survives failed runs and history trimming.
+type pinnedContextModelFactory struct {
+	inner sdkapi.ModelFactory
+}
+
+func (f pinnedContextModelFactory) Model(ctx context.Context) (sdkmodel.Model, error) {
+	model, err := f.inner.Model(ctx)
+	if err != nil || model == nil {
+		return model, err
+	}
+	return &pinnedContextModel{Model: model}, nil
+}
+
+type pinnedContextModel struct {
+	sdkmodel.Model
+}
+
+func (m *pinnedContextModel) Complete(ctx context.Context, req sdkmodel.Request) (*sdkmodel.Response, error) {
+	return m.Model.Complete(ctx, withPinnedSystem(ctx, req))
+}
+
+func (m *pinnedContextModel) CompleteStream(ctx context.Context, req sdkmodel.Request, cb sdkmodel.StreamHandler) error {
+	return m.Model.CompleteStream(ctx, withPinnedSystem(ctx, req), cb)
+}
+
+func withPinnedSystem(ctx context.Context, req sdkmodel.Request) sdkmodel.Request {
+	pinned, _ := ctx.Value(pinnedContextKey{}).(string)
+	if pinned == "" {
+		return req
+	}
+	if strings.TrimSpace(req.System) == "" {
+		req.System = pinned
+	} else {
+		req.System = strings.TrimRight(req.System, "\n") + "\n\n" + pinned
+	}
+	return req
+}
+
+// newToolTurn creates the sdkToolTurn of one run of e.
+func (e *sdkRuntimeEntry) newToolTurn(ctx context.Context, whitelist []string) *sdkToolTurn {
+	return newSDKToolTurn(ctx, e.tools, e.toolOpts, whitelist)
+}
+
+// buildAgentSDKTools adapts existing for agentsdk. The adapters run calls
+// through the sdkToolTurn of the run; batchOpts only applies to calls made
+// outside a run.
+func buildAgentSDKTools(existing []agenttools.Tool, batchOpts agenttools.BatchOptions) []sdktool.Tool {
 	result := make([]sdktool.Tool, 0, len(existing))
 	for _, t := range existing {
 		if t == nil {
 			continue
 		}
-		result = append(result, &sdkToolAdapter{tool: t})
+		result = append(result, &sdkToolAdapter{tool: t, opts: batchOpts})
 	}
 	return result
 }
 
+// toolBatchOptions maps agents.defaults.tool_execution onto the tool dispatcher options.
+func toolBatchOptions(cfg *config.Config, workspace string) agenttools.BatchOptions {
+	opts := agenttools.BatchOptions{Workspace: workspace}
+	if cfg == nil {
+		return opts
+	}
+	te := cfg.Agents.Defaults.ToolExecution
+	opts.MaxParallel = te.MaxParallel
+	opts.CallTimeout = time.Duration(te.CallTimeoutSeconds) * time.Second
+	return opts
+}
+
 func buildContentBlocks(prompt string, media []MainRunMedia) []sdkmodel.ContentBlock {
 	blocks := make([]sdkmodel.ContentBlock, 0, len(media)+1)
 	if strings.TrimSpace(prompt) != "" {
@@ -479,6 +574,7 @@ func buildContentBlocks(prompt string, media []MainRunMedia) []sdkmodel.ContentB
 
 type sdkToolAdapter struct {
 	tool agenttools.Tool
+	opts agenttools.BatchOptions
 }
 
 func (a *sdkToolAdapter) Name() string {
@@ -494,7 +590,13 @@ func (a *sdkToolAdapter) Schema() *sdktool.JSONSchema {
 }
 
 func (a *sdkToolAdapter) Execute(ctx context.Context, params map[string]interface{}) (*sdktool.ToolResult, error) {
-	output, err := a.tool.Execute(ctx, params)
+	var output string
+	var err error
+	if turn := sdkToolTurnFrom(ctx); turn != nil {
+		output, err = turn.Execute(ctx, a.tool, params)
+	} else {
+		output, err = agenttools.NewDispatcher(a.opts).Execute(ctx, a.tool, params)
+	}
 	if err != nil {
 		return &sdktool.ToolResult{
 			Success: false,
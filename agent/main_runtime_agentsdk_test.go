@@ -0,0 +1,272 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkmodel "github.com/cexll/agentsdk-go/pkg/model"
+	sdktool "github.com/cexll/agentsdk-go/pkg/tool"
+	agenttools "github.com/smallnest/goclaw/agent/tools"
+	"github.com/smallnest/goclaw/config"
+)
+
+// concurrencyProbe 按固定延迟执行的只读工具，记录同时运行的调用数峰值
+type concurrencyProbe struct {
+	name    string
+	delay   time.Duration
+	running *int32
+	peak    *int32
+	calls   int32
+}
+
+func (p *concurrencyProbe) Name() string        { return p.name }
+func (p *concurrencyProbe) Description() string { return "concurrency probe" }
+func (p *concurrencyProbe) Mutating() bool      { return false }
+func (p *concurrencyProbe) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+func (p *concurrencyProbe) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	atomic.AddInt32(&p.calls, 1)
+	n := atomic.AddInt32(p.running, 1)
+	defer atomic.AddInt32(p.running, -1)
+	for {
+		peak := atomic.LoadInt32(p.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(p.peak, peak, n) {
+			break
+		}
+	}
+	select {
+	case <-time.After(p.delay):
+		arg, _ := params["arg"].(string)
+		return p.name + ":" + arg, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func newProbe(name string, delay time.Duration) *concurrencyProbe {
+	return &concurrencyProbe{name: name, delay: delay, running: new(int32), peak: new(int32)}
+}
+
+// fakeSDKModel 返回固定响应的模型，只实现 Complete，并记录收到的请求
+type fakeSDKModel struct {
+	sdkmodel.Model
+	resp *sdkmodel.Response
+	reqs []sdkmodel.Request
+}
+
+func (m *fakeSDKModel) Complete(ctx context.Context, req sdkmodel.Request) (*sdkmodel.Response, error) {
+	m.reqs = append(m.reqs, req)
+	return m.resp, nil
+}
+
+func toolCallResponse(name string, n int) *sdkmodel.Response {
+	resp := &sdkmodel.Response{}
+	for i := 0; i < n; i++ {
+		resp.Message.ToolCalls = append(resp.Message.ToolCalls, sdkmodel.ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      name,
+			Arguments: map[string]interface{}{"arg": fmt.Sprintf("%d", i)},
+		})
+	}
+	return resp
+}
+
+// runSequentialTurn 模拟 agentsdk 的一轮：模型返回工具调用后逐个执行，返回各调用输出
+func runSequentialTurn(t testing.TB, ctx context.Context, sdkTools []sdktool.Tool, resp *sdkmodel.Response) []string {
+	model := &toolPrefetchModel{Model: &fakeSDKModel{resp: resp}}
+	out, err := model.Complete(ctx, sdkmodel.Request{})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	byName := make(map[string]sdktool.Tool, len(sdkTools))
+	for _, tool := range sdkTools {
+		byName[tool.Name()] = tool
+	}
+	outputs := make([]string, 0, len(out.Message.ToolCalls))
+	for _, call := range out.Message.ToolCalls {
+		res, err := byName[call.Name].Execute(ctx, call.Arguments)
+		if err != nil || res == nil {
+			t.Fatalf("call %s failed: %+v, %v", call.ID, res, err)
+		}
+		outputs = append(outputs, res.Output)
+	}
+	return outputs
+}
+
+func TestPinnedContextModelAddsPinsToEveryCall(t *testing.T) {
+	inner := &fakeSDKModel{resp: &sdkmodel.Response{}}
+	model := &pinnedContextModel{Model: inner}
+	pinned := "## User-Pinned Facts\n\n1. customer account is 42"
+
+	ctx := withPinnedContext(context.Background(), pinned)
+	for i := 0; i < 2; i++ {
+		if _, err := model.Complete(ctx, sdkmodel.Request{System: "You are goclaw."}); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+	}
+	if _, err := model.Complete(context.Background(), sdkmodel.Request{System: "You are goclaw."}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, err := model.Complete(ctx, sdkmodel.Request{}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	want := "You are goclaw.\n\n" + pinned
+	for i, req := range inner.reqs[:2] {
+		if req.System != want {
+			t.Fatalf("call %d system = %q, want %q", i, req.System, want)
+		}
+	}
+	if got := inner.reqs[2].System; got != "You are goclaw." {
+		t.Fatalf("run without pins changed the system prompt: %q", got)
+	}
+	if got := inner.reqs[3].System; got != pinned {
+		t.Fatalf("empty system prompt = %q, want the pins alone", got)
+	}
+	if ctx := withPinnedContext(context.Background(), "  "); ctx != context.Background() {
+		t.Fatal("blank pinned context should leave ctx unchanged")
+	}
+}
+
+func TestToolTurnRunsReadOnlyCallsOfAResponseInParallel(t *testing.T) {
+	const delay = 40 * time.Millisecond
+	probe := newProbe("probe", delay)
+	existing := []agenttools.Tool{probe}
+	opts := agenttools.BatchOptions{MaxParallel: 4}
+	turn := newSDKToolTurn(context.Background(), existing, opts, nil)
+	defer turn.Close()
+	ctx := withSDKToolTurn(context.Background(), turn)
+
+	start := time.Now()
+	outputs := runSequentialTurn(t, ctx, buildAgentSDKTools(existing, opts), toolCallResponse("probe", 4))
+	elapsed := time.Since(start)
+
+	for i, out := range outputs {
+		if out != fmt.Sprintf("probe:%d", i) {
+			t.Fatalf("results not in call order: %v", outputs)
+		}
+	}
+	if got := atomic.LoadInt32(probe.peak); got != 4 {
+		t.Fatalf("peak concurrency = %d, want the 4 calls to overlap", got)
+	}
+	if got := atomic.LoadInt32(&probe.calls); got != 4 {
+		t.Fatalf("tool ran %d times, want each call once", got)
+	}
+	if elapsed > 3*delay {
+		t.Fatalf("sequentially dispatched turn took %s, want about one call (%s)", elapsed, delay)
+	}
+}
+
+func TestToolTurnDoesNotRunCallsAheadOfAWrite(t *testing.T) {
+	read := newProbe("read_file", 10*time.Millisecond)
+	var writes int32
+	write := agenttools.NewBaseTool("write_file", "write", nil, func(ctx context.Context, params map[string]interface{}) (string, error) {
+		atomic.AddInt32(&writes, 1)
+		return "written", nil
+	})
+	turn := newSDKToolTurn(context.Background(), []agenttools.Tool{read, write}, agenttools.BatchOptions{}, nil)
+	defer turn.Close()
+
+	turn.prefetch([]agenttools.ToolCall{
+		{Name: "read_file", Params: map[string]interface{}{"arg": "a"}},
+		{Name: "read_file", Params: map[string]interface{}{"arg": "b"}},
+		{Name: "write_file", Params: map[string]interface{}{"path": "x"}},
+		{Name: "read_file", Params: map[string]interface{}{"arg": "c"}},
+	})
+
+	turn.mu.Lock()
+	started := len(turn.started)
+	turn.mu.Unlock()
+	if started != 2 {
+		t.Fatalf("started %d calls early, want only the 2 reads before the write", started)
+	}
+	if atomic.LoadInt32(&writes) != 0 {
+		t.Fatal("mutating calls must wait for agentsdk")
+	}
+	if out, err := turn.Execute(context.Background(), read, map[string]interface{}{"arg": "c"}); err != nil || out != "read_file:c" {
+		t.Fatalf("call after the write = %q, %v", out, err)
+	}
+}
+
+func TestToolTurnLimitsParallelCallsPerRun(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Agents.Defaults.ToolExecution = config.ToolExecutionConfig{MaxParallel: 2}
+	opts := toolBatchOptions(cfg, t.TempDir())
+	probe := newProbe("probe", 20*time.Millisecond)
+
+	// 两个会话的运行各自最多 2 个并发调用，互不限流
+	var wg sync.WaitGroup
+	for run := 0; run < 2; run++ {
+		turn := newSDKToolTurn(context.Background(), []agenttools.Tool{probe}, opts, nil)
+		defer turn.Close()
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := turn.Execute(context.Background(), probe, nil); err != nil {
+					t.Errorf("call failed: %v", err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(probe.peak); got != 4 {
+		t.Fatalf("peak concurrency of two runs = %d, want max_parallel 2 per run", got)
+	}
+}
+
+func TestBuildAgentSDKToolsAppliesCallTimeout(t *testing.T) {
+	existing := []agenttools.Tool{newProbe("slow", time.Second)}
+	cfg := &config.Config{}
+	cfg.Agents.Defaults.ToolExecution = config.ToolExecutionConfig{CallTimeoutSeconds: 3}
+	workspace := t.TempDir()
+	opts := toolBatchOptions(cfg, workspace)
+	if opts.CallTimeout != 3*time.Second || opts.Workspace != workspace {
+		t.Fatalf("toolBatchOptions = %+v", opts)
+	}
+	// 缩短超时以加快测试
+	opts.CallTimeout = 20 * time.Millisecond
+
+	res, err := buildAgentSDKTools(existing, opts)[0].Execute(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("adapter must report tool errors in the result, got %v", err)
+	}
+	if res.Success || res.Error == nil {
+		t.Fatalf("expected a timed out result, got %+v", res)
+	}
+
+	turn := newSDKToolTurn(context.Background(), existing, opts, nil)
+	defer turn.Close()
+	if _, err := turn.Execute(context.Background(), existing[0], nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("calls of a run must time out too, got %v", err)
+	}
+}
+
+// BenchmarkAgentSDKToolCalls measures a model response with 4 slow read-only
+// calls that agentsdk executes one after another, at different max_parallel settings.
+func BenchmarkAgentSDKToolCalls(b *testing.B) {
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("max_parallel=%d", workers), func(b *testing.B) {
+			probe := newProbe("slow", 2*time.Millisecond)
+			existing := []agenttools.Tool{probe}
+			opts := agenttools.BatchOptions{MaxParallel: workers}
+			sdkTools := buildAgentSDKTools(existing, opts)
+			resp := toolCallResponse("slow", 4)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				turn := newSDKToolTurn(context.Background(), existing, opts, nil)
+				runSequentialTurn(b, withSDKToolTurn(context.Background(), turn), sdkTools, resp)
+				turn.Close()
+			}
+		})
+	}
+}
@@ -130,6 +130,7 @@ func (m *AgentManager) handleSubagentCompletion(runID string, record *SubagentRu
 			ChildSessionKey:     record.ChildSessionKey,
 			ChildRunID:          record.RunID,
 			RequesterSessionKey: record.RequesterSessionKey,
+			Requester:           record.RequesterRef(),
 			RequesterOrigin:     record.RequesterOrigin,
 			RequesterDisplayKey: record.RequesterDisplayKey,
 			Task:                record.Task,
@@ -244,48 +245,14 @@ func (m *AgentManager) setupSubagentSupport(cfg *config.Config, contextBuilder *
 	})
 
 	// 更新宣告器回调
-	m.subagentAnnouncer = NewSubagentAnnouncer(func(sessionKey, message string) error {
+	m.subagentAnnouncer = NewSubagentAnnouncer(func(requester SessionRef, message string) error {
 		// 发送宣告消息到指定会话
-		return m.sendToSession(sessionKey, message)
+		return m.sendToSession(requester, message)
 	})
 
-	// 创建分身注册表适配器
-	registryAdapter := &subagentRegistryAdapter{registry: m.subagentRegistry}
-
-	// 注册 sessions_spawn 工具
-	spawnTool := tools.NewSubagentSpawnTool(registryAdapter)
-	spawnTool.SetAgentConfigGetter(func(agentID string) *config.AgentConfig {
-		for _, agentCfg := range cfg.Agents.List {
-			if agentCfg.ID == agentID {
-				return &agentCfg
-			}
-		}
-		return nil
-	})
-	spawnTool.SetDefaultConfigGetter(func() *config.AgentDefaults {
-		return &cfg.Agents.Defaults
-	})
-	spawnTool.SetAgentIDGetter(func(sessionKey string) string {
-		// 从会话密钥中解析 agent ID
-		agentID, _, _ := ParseAgentSessionKey(sessionKey)
-		if agentID == "" {
-			// 尝试从绑定中查找
-			for _, entry := range m.bindings {
-				if entry.Agent != nil {
-					return entry.AgentID
-				}
-			}
-		}
-		return agentID
-	})
-	spawnTool.SetOnSpawn(func(result *tools.SubagentSpawnResult) error {
-		return m.handleSubagentSpawn(result)
-	})
-
-	// 注册工具
-	if err := m.tools.RegisterExisting(spawnTool); err != nil {
-		logger.Error("Failed to register sessions_spawn tool", zap.Error(err))
-	}
+	// 注册共享的 sessions_spawn 工具；它在执行时从运行上下文解析路由本次运行的管理器，
+	// 因此多个 AgentManager 共享同一 ToolRegistry 时只需注册一次
+	m.tools.RegisterExistingIfAbsent(tools.NewSubagentSpawnTool(nil))
 
 	// Delegate subagent sandbox "ask" approvals to the main agent (if supported).
 	m.configureSubagentApprovals()
@@ -293,13 +260,22 @@ func (m *AgentManager) setupSubagentSupport(cfg *config.Config, contextBuilder *
 	logger.Info("Subagent support configured")
 }
 
-// subagentRegistryAdapter 分身注册表适配器
-type subagentRegistryAdapter struct {
-	registry *SubagentRegistry
+// RunContext 将本管理器绑定到运行上下文，使共享工具（如 sessions_spawn）能找到路由本次运行的管理器。
+// 直接调用 MainRuntime 的入口（CLI、TUI）需要自行包装运行上下文。m 为 nil 时原样返回 ctx。
+func (m *AgentManager) RunContext(ctx context.Context) context.Context {
+	if m == nil {
+		return ctx
+	}
+	return tools.WithSubagentSpawnProvider(ctx, &subagentSpawnProvider{m: m})
+}
+
+// subagentSpawnProvider 以 AgentManager 实现 tools.SubagentSpawnProvider
+type subagentSpawnProvider struct {
+	m *AgentManager
 }
 
 // RegisterRun 注册分身运行
-func (a *subagentRegistryAdapter) RegisterRun(params *tools.SubagentRunParams) error {
+func (p *subagentSpawnProvider) RegisterRun(params *tools.SubagentRunParams) error {
 	// 转换 RequesterOrigin
 	var requesterOrigin *DeliveryContext
 	if params.RequesterOrigin != nil {
@@ -311,7 +287,7 @@ func (a *subagentRegistryAdapter) RegisterRun(params *tools.SubagentRunParams) e
 		}
 	}
 
-	return a.registry.RegisterRun(&SubagentRunParams{
+	return p.m.subagentRegistry.RegisterRun(&SubagentRunParams{
 		RunID:               params.RunID,
 		ChildSessionKey:     params.ChildSessionKey,
 		RequesterSessionKey: params.RequesterSessionKey,
@@ -328,6 +304,49 @@ func (a *subagentRegistryAdapter) RegisterRun(params *tools.SubagentRunParams) e
 	})
 }
 
+// AgentConfig 返回指定 Agent 的配置
+func (p *subagentSpawnProvider) AgentConfig(agentID string) *config.AgentConfig {
+	if p.m.cfg == nil {
+		return nil
+	}
+	for _, agentCfg := range p.m.cfg.Agents.List {
+		if agentCfg.ID == agentID {
+			return &agentCfg
+		}
+	}
+	return nil
+}
+
+// AgentDefaults 返回 Agent 默认配置
+func (p *subagentSpawnProvider) AgentDefaults() *config.AgentDefaults {
+	if p.m.cfg == nil {
+		return nil
+	}
+	return &p.m.cfg.Agents.Defaults
+}
+
+// AgentIDForSession 从会话密钥中解析 agent ID，失败时回退到绑定
+func (p *subagentSpawnProvider) AgentIDForSession(sessionKey string) string {
+	agentID, _, _ := ParseAgentSessionKey(sessionKey)
+	if agentID != "" {
+		return agentID
+	}
+
+	p.m.mu.RLock()
+	defer p.m.mu.RUnlock()
+	for _, entry := range p.m.bindings {
+		if entry.Agent != nil {
+			return entry.AgentID
+		}
+	}
+	return ""
+}
+
+// OnSubagentSpawn 启动分身运行
+func (p *subagentSpawnProvider) OnSubagentSpawn(result *tools.SubagentSpawnResult) error {
+	return p.m.handleSubagentSpawn(result)
+}
+
 // handleSubagentSpawn 处理分身生成
 func (m *AgentManager) handleSubagentSpawn(result *tools.SubagentSpawnResult) error {
 	if m.subagentRuntime == nil {
@@ -462,25 +481,16 @@ func (m *AgentManager) handleSubagentSpawn(result *tools.SubagentSpawnResult) er
 }
 
 // sendToSession 发送消息到指定会话
-func (m *AgentManager) sendToSession(sessionKey, message string) error {
-	channel, accountID, chatID := parseRequesterSessionKey(sessionKey)
-	if channel == "" {
-		channel = "cli"
-	}
-	if accountID == "" {
-		accountID = "default"
-	}
-	if chatID == "" {
-		chatID = "default"
-	}
+func (m *AgentManager) sendToSession(ref SessionRef, message string) error {
+	ref = ref.WithDefaults("cli")
 	inbound := &bus.InboundMessage{
-		Channel:   channel,
-		AccountID: accountID,
-		ChatID:    chatID,
+		Channel:   ref.Channel,
+		AccountID: ref.AccountID,
+		ChatID:    ref.ChatID,
 		Content:   message,
 		Metadata: map[string]interface{}{
 			"source":                "subagent_announce",
-			"requester_session_key": sessionKey,
+			"requester_session_key": ref.String(),
 		},
 		Timestamp: time.Now(),
 	}
@@ -586,20 +596,6 @@ func normalizeRuntimeStatus(status string) string {
 	}
 }
 
-func parseRequesterSessionKey(sessionKey string) (channel string, accountID string, chatID string) {
-	parts := strings.Split(sessionKey, ":")
-	switch {
-	case len(parts) >= 3:
-		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(strings.Join(parts[2:], ":"))
-	case len(parts) == 2:
-		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), "default"
-	case len(parts) == 1 && strings.TrimSpace(parts[0]) != "":
-		return "cli", "default", strings.TrimSpace(parts[0])
-	default:
-		return "cli", "default", "default"
-	}
-}
-
 func (m *AgentManager) getWorkspaceRoot() string {
 	if strings.TrimSpace(m.workspace) != "" {
 		return strings.TrimSpace(m.workspace)
@@ -753,6 +749,18 @@ func (m *AgentManager) handleInboundMessage(ctx context.Context, msg *bus.Inboun
 		zap.String("account_id", msg.AccountID),
 		zap.String("chat_id", msg.ChatID))
 
+	// 置顶笔记按 chat 保存，不随会话轮换
+	chatKey := PinChatKey(msg.Channel, msg.AccountID, msg.ChatID)
+	if cmd, ok := ParsePinCommand(msg.Content); ok {
+		reply := ExecutePinCommand(m.contextBuilder.Pins(), chatKey, cmd)
+		m.publishToBus(ctx, msg.Channel, msg.ChatID, msg.Metadata, AgentMessage{
+			Role:      RoleAssistant,
+			Content:   []ContentBlock{TextContent{Text: reply}},
+			Timestamp: time.Now().UnixMilli(),
+		})
+		return nil
+	}
+
 	// 生成会话键（显式 chat 会复用，默认 chat 自动生成新会话）
 	sessionKey, fresh := ResolveSessionKey(SessionKeyOptions{
 		Channel:        msg.Channel,
@@ -796,6 +804,7 @@ func (m *AgentManager) handleInboundMessage(ctx context.Context, msg *bus.Inboun
 	ctx = context.WithValue(ctx, agentruntime.CtxChannel, strings.TrimSpace(msg.Channel))
 	ctx = context.WithValue(ctx, agentruntime.CtxAccountID, strings.TrimSpace(msg.AccountID))
 	ctx = context.WithValue(ctx, agentruntime.CtxChatID, strings.TrimSpace(msg.ChatID))
+	ctx = m.RunContext(ctx)
 
 	if m.mainRuntime == nil {
 		return fmt.Errorf("main runtime is not configured")
@@ -813,12 +822,13 @@ func (m *AgentManager) handleInboundMessage(ctx context.Context, msg *bus.Inboun
 
 	runWorkspace := agent.GetWorkspace()
 	runResp, runErr := m.mainRuntime.Run(ctx, MainRunRequest{
-		AgentID:      strings.TrimSpace(agentID),
-		SessionKey:   sessionKey,
-		Prompt:       msg.Content,
-		SystemPrompt: agent.GetState().SystemPrompt,
-		Workspace:    runWorkspace,
-		Media:        media,
+		AgentID:       strings.TrimSpace(agentID),
+		SessionKey:    sessionKey,
+		Prompt:        msg.Content,
+		SystemPrompt:  agent.GetState().SystemPrompt,
+		Workspace:     runWorkspace,
+		Media:         media,
+		PinnedContext: m.contextBuilder.BuildPinnedContext(chatKey),
 		Metadata: map[string]any{
 			"channel":    msg.Channel,
 			"account_id": msg.AccountID,
@@ -908,6 +918,16 @@ func (m *AgentManager) RunStream(ctx context.Context, msg *bus.InboundMessage, o
 		return "", fmt.Errorf("no agent found for stream request")
 	}
 
+	// 显式会话键同样归一到 chat 键，保证与轮换后的会话共享置顶笔记
+	chatKey := PinChatKeyFor(opts.ExplicitSessionKey, SessionRef{
+		Channel:   msg.Channel,
+		AccountID: msg.AccountID,
+		ChatID:    msg.ChatID,
+	})
+	if cmd, ok := ParsePinCommand(msg.Content); ok {
+		return ExecutePinCommand(m.contextBuilder.Pins(), chatKey, cmd), nil
+	}
+
 	sessionKey, fresh := ResolveSessionKey(SessionKeyOptions{
 		Explicit:       opts.ExplicitSessionKey,
 		Channel:        msg.Channel,
@@ -946,6 +966,7 @@ func (m *AgentManager) RunStream(ctx context.Context, msg *bus.InboundMessage, o
 	ctx = context.WithValue(ctx, agentruntime.CtxChannel, strings.TrimSpace(msg.Channel))
 	ctx = context.WithValue(ctx, agentruntime.CtxAccountID, strings.TrimSpace(msg.AccountID))
 	ctx = context.WithValue(ctx, agentruntime.CtxChatID, strings.TrimSpace(msg.ChatID))
+	ctx = m.RunContext(ctx)
 
 	media := make([]MainRunMedia, 0, len(msg.Media))
 	for _, item := range msg.Media {
@@ -964,12 +985,13 @@ func (m *AgentManager) RunStream(ctx context.Context, msg *bus.InboundMessage, o
 
 	runWorkspace := agent.GetWorkspace()
 	stream, err := streamer.RunStream(ctx, MainRunRequest{
-		AgentID:      strings.TrimSpace(agentID),
-		SessionKey:   sessionKey,
-		Prompt:       msg.Content,
-		SystemPrompt: agent.GetState().SystemPrompt,
-		Workspace:    runWorkspace,
-		Media:        media,
+		AgentID:       strings.TrimSpace(agentID),
+		SessionKey:    sessionKey,
+		Prompt:        msg.Content,
+		SystemPrompt:  agent.GetState().SystemPrompt,
+		Workspace:     runWorkspace,
+		Media:         media,
+		PinnedContext: m.contextBuilder.BuildPinnedContext(chatKey),
 		Metadata: map[string]any{
 			"channel":    msg.Channel,
 			"account_id": msg.AccountID,
@@ -1049,6 +1071,10 @@ func (m *AgentManager) exportSessionMarkdown(sess *session.Session) {
 	if m == nil || sess == nil || m.cfg == nil || m.sessionMgr == nil {
 		return
 	}
+	// 只读模式下不把会话导出到记忆目录
+	if m.cfg.ReadOnly.Enabled {
+		return
+	}
 
 	memCfg := m.cfg.Memory
 	if strings.TrimSpace(memCfg.Backend) != "" && strings.TrimSpace(memCfg.Backend) != "memsearch" {
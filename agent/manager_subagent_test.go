@@ -3,10 +3,12 @@ package agent
 import (
 	"context"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	sdkapi "github.com/cexll/agentsdk-go/pkg/api"
 	agentruntime "github.com/smallnest/goclaw/agent/runtime"
 	"github.com/smallnest/goclaw/agent/tools"
 	"github.com/smallnest/goclaw/config"
@@ -209,52 +211,98 @@ func TestHandleSubagentSpawnBuildsRuntimeRequestAndMarksCompleted(t *testing.T)
 	}
 }
 
-func TestParseRequesterSessionKey(t *testing.T) {
-	tests := []struct {
-		name      string
-		in        string
-		channel   string
-		accountID string
-		chatID    string
-	}{
-		{
-			name:      "full triple",
-			in:        "telegram:bot1:chat42",
-			channel:   "telegram",
-			accountID: "bot1",
-			chatID:    "chat42",
-		},
-		{
-			name:      "double fallback chat",
-			in:        "cli:default",
-			channel:   "cli",
-			accountID: "default",
-			chatID:    "default",
-		},
-		{
-			name:      "single fallback",
-			in:        "standalone-chat",
-			channel:   "cli",
-			accountID: "default",
-			chatID:    "standalone-chat",
-		},
-		{
-			name:      "empty fallback all",
-			in:        "",
-			channel:   "cli",
-			accountID: "default",
-			chatID:    "default",
-		},
+func TestManagersShareToolRegistry(t *testing.T) {
+	toolRegistry := NewToolRegistry()
+	newManager := func(allowAgents []string) *AgentManager {
+		dir := t.TempDir()
+		mgr := NewAgentManager(&NewAgentManagerConfig{
+			Tools:     toolRegistry,
+			DataDir:   dir,
+			Workspace: dir,
+		})
+		cfg := &config.Config{
+			Agents: config.AgentsConfig{
+				List: []config.AgentConfig{{
+					ID:        "main",
+					Default:   true,
+					Workspace: dir,
+					Subagents: &config.AgentSubagentConfig{AllowAgents: allowAgents},
+				}},
+			},
+		}
+		if err := mgr.SetupFromConfig(cfg, nil); err != nil {
+			t.Fatalf("SetupFromConfig() failed: %v", err)
+		}
+		return mgr
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			channel, accountID, chatID := parseRequesterSessionKey(tt.in)
-			if channel != tt.channel || accountID != tt.accountID || chatID != tt.chatID {
-				t.Fatalf("parseRequesterSessionKey(%q) = (%q,%q,%q), want (%q,%q,%q)",
-					tt.in, channel, accountID, chatID, tt.channel, tt.accountID, tt.chatID)
-			}
+	first := newManager([]string{"helper"})
+	second := newManager(nil)
+	if toolRegistry.Count() != 1 {
+		t.Fatalf("expected a single shared sessions_spawn tool, got %d tools", toolRegistry.Count())
+	}
+	tool, ok := toolRegistry.GetExisting("sessions_spawn")
+	if !ok {
+		t.Fatalf("sessions_spawn not registered")
+	}
+
+	spawn := func(mgr *AgentManager, sessionKey string) string {
+		ctx := context.WithValue(context.Background(), agentruntime.CtxSessionKey, sessionKey)
+		ctx = context.WithValue(ctx, agentruntime.CtxAgentID, "main")
+		out, err := tool.Execute(mgr.RunContext(ctx), map[string]interface{}{
+			"task":     "summarize the incident",
+			"agent_id": "helper",
 		})
+		if err != nil {
+			t.Fatalf("Execute() returned error: %v", err)
+		}
+		return out
+	}
+
+	if out := spawn(first, "telegram:bot1:chat1"); !strings.Contains(out, "Subagent spawned successfully.") {
+		t.Fatalf("first manager should allow helper, got: %s", out)
+	}
+	if out := spawn(second, "telegram:bot2:chat2"); !strings.HasPrefix(out, "Forbidden:") {
+		t.Fatalf("second manager should use its own permissions, got: %s", out)
+	}
+
+	if runs := first.subagentRegistry.ListRunsForRequester("telegram:bot1:chat1"); len(runs) != 1 {
+		t.Fatalf("first manager should own the spawned run, got %d runs", len(runs))
+	}
+	if runs := second.subagentRegistry.ListRunsForRequester("telegram:bot1:chat1"); len(runs) != 0 {
+		t.Fatalf("run routed to the first manager leaked into the second: %d runs", len(runs))
+	}
+}
+
+func TestAnnounceFlowRoutesToTypedRequester(t *testing.T) {
+	var got []SessionRef
+	announcer := NewSubagentAnnouncer(func(requester SessionRef, message string) error {
+		got = append(got, requester)
+		return nil
+	})
+
+	// A chat ID containing the separator must not shift into other fields.
+	key, _ := ResolveSessionKey(SessionKeyOptions{Channel: "telegram", AccountID: "bot1", ChatID: "thread:7"})
+	if err := announcer.RunAnnounceFlow(&SubagentAnnounceParams{
+		RequesterSessionKey: key,
+		Task:                "collect logs",
+		Outcome:             &SubagentRunOutcome{Status: "ok"},
+	}); err != nil {
+		t.Fatalf("RunAnnounceFlow() failed: %v", err)
+	}
+
+	explicit := SessionRef{Channel: "slack", AccountID: "team:eu", ChatID: "C01"}
+	if err := announcer.RunAnnounceFlow(&SubagentAnnounceParams{
+		RequesterSessionKey: "ignored:when:typed",
+		Requester:           explicit,
+		Task:                "collect logs",
+	}); err != nil {
+		t.Fatalf("RunAnnounceFlow() failed: %v", err)
+	}
+
+	want := []SessionRef{{Channel: "telegram", AccountID: "bot1", ChatID: "thread:7"}, explicit}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("announce routed to %+v, want %+v", got, want)
 	}
 }
 
@@ -276,3 +324,72 @@ func TestNormalizeRuntimeStatus(t *testing.T) {
 		}
 	}
 }
+
+func TestListRunsForRequesterMatchesUnescapedKey(t *testing.T) {
+	registry := NewSubagentRegistry(t.TempDir())
+	// 旧版本记录的请求者 key 未转义 chat ID
+	if err := registry.RegisterRun(&SubagentRunParams{
+		RunID:               "run-legacy",
+		ChildSessionKey:     "agent:helper:subagent:1",
+		RequesterSessionKey: "telegram:bot1:group:42",
+		Task:                "summarize",
+	}); err != nil {
+		t.Fatalf("RegisterRun: %v", err)
+	}
+
+	key := PinChatKey("telegram", "bot1", "group:42")
+	if runs := registry.ListRunsForRequester(key); len(runs) != 1 || runs[0].RunID != "run-legacy" {
+		t.Fatalf("ListRunsForRequester(%q) = %+v, want the legacy run", key, runs)
+	}
+	if runs := registry.ListRunsForRequester("telegram:bot1:group%3A43"); len(runs) != 0 {
+		t.Fatalf("runs of another chat matched: %+v", runs)
+	}
+}
+
+func TestRunContextOfNilManagerLeavesContextUnbound(t *testing.T) {
+	var mgr *AgentManager
+	ctx := context.WithValue(context.Background(), agentruntime.CtxSessionKey, "cli:default:chat")
+	if got := mgr.RunContext(ctx); got != ctx {
+		t.Fatalf("nil manager should return ctx unchanged")
+	}
+
+	out, err := tools.NewSubagentSpawnTool(nil).Execute(mgr.RunContext(ctx), map[string]interface{}{"task": "x"})
+	if err != nil {
+		t.Fatalf("Execute() returned error: %v", err)
+	}
+	if !strings.Contains(out, "not bound to an agent manager") {
+		t.Fatalf("unbound run should report the missing manager, got: %s", out)
+	}
+}
+
+// spawnBindingMainRuntime 记录 Run 的上下文是否绑定了分身生成 provider
+type spawnBindingMainRuntime struct {
+	bound bool
+}
+
+func (r *spawnBindingMainRuntime) Run(ctx context.Context, _ MainRunRequest) (*MainRunResult, error) {
+	_, r.bound = tools.SubagentSpawnProviderFromContext(ctx)
+	return &MainRunResult{Output: `{"decision":"deny"}`}, nil
+}
+
+func (r *spawnBindingMainRuntime) Close() error { return nil }
+
+func TestSubagentApprovalRunIsBoundToManager(t *testing.T) {
+	tmp := t.TempDir()
+	runtime := &spawnBindingMainRuntime{}
+	approver := &Agent{state: NewAgentState(), workspace: tmp}
+	mgr := &AgentManager{
+		subagentRegistry: NewSubagentRegistry(tmp),
+		mainRuntime:      runtime,
+		defaultAgent:     approver,
+		agents:           map[string]*Agent{"main": approver},
+	}
+
+	if _, err := mgr.decideSubagentPermissionByMainAgent(context.Background(),
+		agentruntime.SubagentRunRequest{RunID: "run-1"}, sdkapi.PermissionRequest{ToolName: "bash"}); err != nil {
+		t.Fatalf("decideSubagentPermissionByMainAgent() returned error: %v", err)
+	}
+	if !runtime.bound {
+		t.Fatalf("approval run should be bound to the manager for shared tools")
+	}
+}
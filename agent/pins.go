@@ -0,0 +1,311 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxPinsPerChat caps how many notes a single chat can pin.
+	MaxPinsPerChat = 20
+	// MaxPinBytesPerChat caps the total size of a chat's pinned notes.
+	MaxPinBytesPerChat = 4096
+)
+
+// Pin is a note the user pinned to a chat.
+type Pin struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type pinFile struct {
+	ChatKey string `json:"chat_key"`
+	Pins    []Pin  `json:"pins"`
+}
+
+// PinStore keeps pinned notes per chat key (channel:account:chat).
+//
+// Each chat gets a small JSON file under <workspace>/pins. Pins are keyed by
+// chat rather than session, so they survive fresh-session rotation and
+// restarts, and they live outside memory/ so memory backends never index them.
+type PinStore struct {
+	dir      string
+	readOnly bool
+	mu       sync.Mutex
+}
+
+// NewPinStore creates a pin store rooted at workspace.
+func NewPinStore(workspace string) *PinStore {
+	return &PinStore{dir: filepath.Join(workspace, "pins")}
+}
+
+// SetReadOnly makes Add and Remove fail, for read-only deployments.
+func (s *PinStore) SetReadOnly(readOnly bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readOnly = readOnly
+}
+
+// PinChatKey returns the key pins are stored under for a chat. Unlike the
+// session key it never rotates: the default chat maps to "<channel>:<account>:default".
+func PinChatKey(channel, accountID, chatID string) string {
+	key, _ := ResolveSessionKey(SessionKeyOptions{
+		Channel:   channel,
+		AccountID: accountID,
+		ChatID:    chatID,
+	})
+	return key
+}
+
+// PinChatKeyFor returns the pin key of the chat a run belongs to. An explicit
+// session key is parsed and defaulted like a generated one (using chat's
+// channel), so "tg:bot" pins the same chat as the rotating sessions of tg/bot's
+// default chat. Without one, chat's components are used.
+func PinChatKeyFor(explicitSessionKey string, chat SessionRef) string {
+	if explicit := strings.TrimSpace(explicitSessionKey); explicit != "" {
+		chat = ParseSessionRef(explicit).WithDefaults(chat.Channel)
+	}
+	return PinChatKey(chat.Channel, chat.AccountID, chat.ChatID)
+}
+
+// List returns the pins of chatKey in pin order.
+func (s *PinStore) List(chatKey string) ([]Pin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(chatKey)
+}
+
+// Add pins text to chatKey and returns its 1-based position.
+func (s *PinStore) Add(chatKey, text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, fmt.Errorf("pin text is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return 0, errPinsReadOnly
+	}
+	pins, err := s.load(chatKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(pins) >= MaxPinsPerChat {
+		return 0, fmt.Errorf("chat already has %d pins (max %d), unpin one first", len(pins), MaxPinsPerChat)
+	}
+	total := len(text)
+	for _, p := range pins {
+		total += len(p.Text)
+	}
+	if total > MaxPinBytesPerChat {
+		return 0, fmt.Errorf("pins would total %d bytes (max %d), unpin something or shorten the note", total, MaxPinBytesPerChat)
+	}
+
+	pins = append(pins, Pin{Text: text, CreatedAt: time.Now()})
+	if err := s.save(chatKey, pins); err != nil {
+		return 0, err
+	}
+	return len(pins), nil
+}
+
+// Remove unpins the n-th (1-based) note of chatKey and returns it.
+func (s *PinStore) Remove(chatKey string, n int) (Pin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return Pin{}, errPinsReadOnly
+	}
+	pins, err := s.load(chatKey)
+	if err != nil {
+		return Pin{}, err
+	}
+	if n < 1 || n > len(pins) {
+		return Pin{}, fmt.Errorf("no pin #%d (chat has %d)", n, len(pins))
+	}
+
+	removed := pins[n-1]
+	pins = append(pins[:n-1], pins[n:]...)
+	if err := s.save(chatKey, pins); err != nil {
+		return Pin{}, err
+	}
+	return removed, nil
+}
+
+func (s *PinStore) path(chatKey string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(chatKey)))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// legacyPath returns the file older versions stored chatKey's pins in, before
+// chat keys were escaped, or "" when chatKey has nothing escaped.
+func (s *PinStore) legacyPath(chatKey string) string {
+	legacy := LegacySessionKey(chatKey)
+	if legacy == strings.TrimSpace(chatKey) {
+		return ""
+	}
+	return s.path(legacy)
+}
+
+func (s *PinStore) load(chatKey string) ([]Pin, error) {
+	if strings.TrimSpace(chatKey) == "" {
+		return nil, fmt.Errorf("chat key is required")
+	}
+	data, err := os.ReadFile(s.path(chatKey))
+	if os.IsNotExist(err) {
+		if legacy := s.legacyPath(chatKey); legacy != "" {
+			data, err = os.ReadFile(legacy)
+		}
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read pins: %w", err)
+	}
+	var f pinFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse pins: %w", err)
+	}
+	return f.Pins, nil
+}
+
+func (s *PinStore) save(chatKey string, pins []Pin) error {
+	path := s.path(chatKey)
+	if len(pins) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove pins: %w", err)
+		}
+		return s.removeLegacy(chatKey)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create pins directory: %w", err)
+	}
+	data, err := json.MarshalIndent(pinFile{ChatKey: strings.TrimSpace(chatKey), Pins: pins}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := fmt.Sprintf("%s.%d.tmp", path, time.Now().UnixNano())
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write pins: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write pins: %w", err)
+	}
+	return s.removeLegacy(chatKey)
+}
+
+// removeLegacy deletes chatKey's legacy pin file once its pins have been
+// saved under the current key, so they are never read back from there.
+func (s *PinStore) removeLegacy(chatKey string) error {
+	legacy := s.legacyPath(chatKey)
+	if legacy == "" {
+		return nil
+	}
+	if err := os.Remove(legacy); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove legacy pins: %w", err)
+	}
+	return nil
+}
+
+// FormatPinnedContext renders pins as the labeled block sent ahead of a
+// user turn. It returns "" when there are no pins.
+func FormatPinnedContext(pins []Pin) string {
+	if len(pins) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## User-Pinned Facts\n\n")
+	sb.WriteString("The user pinned these facts to this chat. They were written by the user (not retrieved from memory) and stay in effect across sessions until unpinned.\n\n")
+	for i, p := range pins {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, p.Text)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// PinCommand is a parsed pin slash command or channel keyword.
+type PinCommand struct {
+	Action string // "add", "list" or "remove"
+	Text   string
+	Index  int
+}
+
+// ParsePinCommand recognizes "/pin <text>", "/pins [list]" and "/unpin <n>".
+// Channels that swallow slash commands can use the same words prefixed with
+// "#" instead ("#pin <text>", "#pins", "#unpin <n>").
+func ParsePinCommand(content string) (PinCommand, bool) {
+	content = strings.TrimSpace(content)
+	if len(content) < 2 || (content[0] != '/' && content[0] != '#') {
+		return PinCommand{}, false
+	}
+
+	word, rest, _ := strings.Cut(content[1:], " ")
+	rest = strings.TrimSpace(rest)
+	switch strings.ToLower(word) {
+	case "pin":
+		return PinCommand{Action: "add", Text: rest}, true
+	case "pins":
+		if rest != "" && !strings.EqualFold(rest, "list") {
+			return PinCommand{}, false
+		}
+		return PinCommand{Action: "list"}, true
+	case "unpin":
+		n, _ := strconv.Atoi(rest)
+		return PinCommand{Action: "remove", Index: n}, true
+	}
+	return PinCommand{}, false
+}
+
+// ExecutePinCommand applies cmd to chatKey and returns the reply shown to the user.
+func ExecutePinCommand(store *PinStore, chatKey string, cmd PinCommand) string {
+	if store == nil {
+		return "Pinned notes are not available."
+	}
+	switch cmd.Action {
+	case "add":
+		if strings.TrimSpace(cmd.Text) == "" {
+			return "Usage: /pin <text>"
+		}
+		n, err := store.Add(chatKey, cmd.Text)
+		if err != nil {
+			return fmt.Sprintf("Failed to pin: %v", err)
+		}
+		return fmt.Sprintf("📌 Pinned #%d.", n)
+	case "list":
+		pins, err := store.List(chatKey)
+		if err != nil {
+			return fmt.Sprintf("Failed to list pins: %v", err)
+		}
+		if len(pins) == 0 {
+			return "No pinned notes for this chat."
+		}
+		var sb strings.Builder
+		sb.WriteString("📌 Pinned notes:\n")
+		for i, p := range pins {
+			fmt.Fprintf(&sb, "%d. %s\n", i+1, p.Text)
+		}
+		return strings.TrimRight(sb.String(), "\n")
+	case "remove":
+		if cmd.Index < 1 {
+			return "Usage: /unpin <n>"
+		}
+		removed, err := store.Remove(chatKey, cmd.Index)
+		if err != nil {
+			return fmt.Sprintf("Failed to unpin: %v", err)
+		}
+		return fmt.Sprintf("Unpinned #%d: %s", cmd.Index, removed.Text)
+	}
+	return "Unknown pin command."
+}
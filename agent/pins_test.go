@@ -0,0 +1,308 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smallnest/goclaw/agent/tools"
+	"github.com/smallnest/goclaw/bus"
+	"github.com/smallnest/goclaw/config"
+	"github.com/smallnest/goclaw/session"
+)
+
+func TestPinStoreAddListRemove(t *testing.T) {
+	store := NewPinStore(t.TempDir())
+	chat := "qq:acct:g123"
+
+	for _, text := range []string{"customer account is 42", "repo is smallnest/goclaw", "prefers short answers"} {
+		if _, err := store.Add(chat, text); err != nil {
+			t.Fatalf("Add(%q): %v", text, err)
+		}
+	}
+
+	removed, err := store.Remove(chat, 2)
+	if err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if removed.Text != "repo is smallnest/goclaw" {
+		t.Fatalf("removed wrong pin: %q", removed.Text)
+	}
+
+	pins, err := store.List(chat)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(pins) != 2 || pins[0].Text != "customer account is 42" || pins[1].Text != "prefers short answers" {
+		t.Fatalf("unexpected pins after remove: %+v", pins)
+	}
+
+	if _, err := store.Remove(chat, 5); err == nil {
+		t.Fatalf("expected out-of-range remove to fail")
+	}
+	if other, _ := store.List("qq:acct:g999"); len(other) != 0 {
+		t.Fatalf("pins leaked into another chat: %+v", other)
+	}
+}
+
+func TestPinStoreSurvivesReopen(t *testing.T) {
+	workspace := t.TempDir()
+	if _, err := NewPinStore(workspace).Add("telegram:bot:1", "timezone is UTC+8"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	pins, err := NewPinStore(workspace).List("telegram:bot:1")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(pins) != 1 || pins[0].Text != "timezone is UTC+8" {
+		t.Fatalf("expected pin to persist, got %+v", pins)
+	}
+
+	// Pins must stay out of memory/, which memory backends index.
+	if _, err := os.Stat(filepath.Join(workspace, "memory")); !os.IsNotExist(err) {
+		t.Fatalf("pin store should not write under memory/")
+	}
+}
+
+func TestPinStoreReadsPinsSavedUnderUnescapedKey(t *testing.T) {
+	workspace := t.TempDir()
+	// 旧版本按原样保存含 ":" 的 chat ID
+	if _, err := NewPinStore(workspace).Add("telegram:bot:group:42", "ticket is #7"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	store := NewPinStore(workspace)
+	chat := PinChatKey("telegram", "bot", "group:42")
+	pins, err := store.List(chat)
+	if err != nil || len(pins) != 1 || pins[0].Text != "ticket is #7" {
+		t.Fatalf("List(%q) = %+v, %v; want the legacy pin", chat, pins, err)
+	}
+
+	if _, err := store.Add(chat, "deadline is Friday"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, err := os.Stat(store.path("telegram:bot:group:42")); !os.IsNotExist(err) {
+		t.Fatalf("legacy pin file should be removed after saving, stat err = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := store.Remove(chat, 1); err != nil {
+			t.Fatalf("Remove: %v", err)
+		}
+	}
+	if pins, _ := store.List(chat); len(pins) != 0 {
+		t.Fatalf("removed pins came back: %+v", pins)
+	}
+}
+
+func TestPinStoreLimits(t *testing.T) {
+	store := NewPinStore(t.TempDir())
+	chat := "cli:default:default"
+
+	for i := 0; i < MaxPinsPerChat; i++ {
+		if _, err := store.Add(chat, "note"); err != nil {
+			t.Fatalf("Add #%d: %v", i+1, err)
+		}
+	}
+	if _, err := store.Add(chat, "one too many"); err == nil {
+		t.Fatalf("expected count limit to be enforced")
+	}
+
+	big := NewPinStore(t.TempDir())
+	if _, err := big.Add(chat, strings.Repeat("x", MaxPinBytesPerChat+1)); err == nil {
+		t.Fatalf("expected size limit to be enforced")
+	}
+	if _, err := big.Add(chat, "   "); err == nil {
+		t.Fatalf("expected empty pin to be rejected")
+	}
+}
+
+func TestPinStoreReadOnly(t *testing.T) {
+	store := NewPinStore(t.TempDir())
+	chat := "cli:default:default"
+	if _, err := store.Add(chat, "kept"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	store.SetReadOnly(true)
+	if _, err := store.Add(chat, "new"); !errors.Is(err, tools.ErrReadOnly) {
+		t.Fatalf("Add in read-only mode error = %v, want ErrReadOnly", err)
+	}
+	if _, err := store.Remove(chat, 1); !errors.Is(err, tools.ErrReadOnly) {
+		t.Fatalf("Remove in read-only mode error = %v, want ErrReadOnly", err)
+	}
+	if pins, err := store.List(chat); err != nil || len(pins) != 1 {
+		t.Fatalf("List in read-only mode = %+v, %v", pins, err)
+	}
+}
+
+func TestPinChatKeyIgnoresSessionRotation(t *testing.T) {
+	if got := PinChatKey("qq", "acct", ""); got != "qq:acct:default" {
+		t.Fatalf("PinChatKey default chat = %q", got)
+	}
+	if got := PinChatKey("qq", "acct", "g123"); got != "qq:acct:g123" {
+		t.Fatalf("PinChatKey = %q", got)
+	}
+}
+
+func TestParsePinCommand(t *testing.T) {
+	tests := []struct {
+		in   string
+		ok   bool
+		want PinCommand
+	}{
+		{in: "/pin account id is 42", ok: true, want: PinCommand{Action: "add", Text: "account id is 42"}},
+		{in: "#pin  repo: goclaw ", ok: true, want: PinCommand{Action: "add", Text: "repo: goclaw"}},
+		{in: "/pins", ok: true, want: PinCommand{Action: "list"}},
+		{in: "/pins list", ok: true, want: PinCommand{Action: "list"}},
+		{in: "#PINS", ok: true, want: PinCommand{Action: "list"}},
+		{in: "/unpin 2", ok: true, want: PinCommand{Action: "remove", Index: 2}},
+		{in: "#unpin x", ok: true, want: PinCommand{Action: "remove"}},
+		{in: "/pins are great", ok: false},
+		{in: "/pinned", ok: false},
+		{in: "please pin this", ok: false},
+		{in: "#", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParsePinCommand(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParsePinCommand(%q) = %+v, %v; want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestContextBuilderInjectsPinnedFacts(t *testing.T) {
+	workspace := t.TempDir()
+	builder := NewContextBuilder(NewMemoryStore(workspace, nil, "", 0, false), workspace)
+
+	if got := builder.BuildPinnedContext("qq:acct:g123"); got != "" {
+		t.Fatalf("expected no pinned context without pins, got %q", got)
+	}
+
+	reply := ExecutePinCommand(builder.Pins(), "qq:acct:g123", PinCommand{Action: "add", Text: "customer account is 42"})
+	if !strings.Contains(reply, "#1") {
+		t.Fatalf("unexpected pin reply %q", reply)
+	}
+
+	got := builder.BuildPinnedContext("qq:acct:g123")
+	if !strings.HasPrefix(got, "## User-Pinned Facts") || !strings.Contains(got, "1. customer account is 42") {
+		t.Fatalf("pinned facts not rendered:\n%s", got)
+	}
+	if other := builder.BuildPinnedContext("qq:acct:g999"); other != "" {
+		t.Fatalf("pins of another chat rendered: %q", other)
+	}
+}
+
+func TestPinChatKeyFor(t *testing.T) {
+	chat := SessionRef{Channel: "sdk", AccountID: "app"}
+	tests := []struct {
+		explicit string
+		want     string
+	}{
+		{explicit: "", want: "sdk:app:default"},
+		{explicit: "sdk:app", want: "sdk:app:default"},
+		{explicit: " sdk:app:default ", want: "sdk:app:default"},
+		{explicit: "tg:bot:a%3Ab", want: "tg:bot:a%3Ab"},
+		{explicit: "tg:bot:a:b", want: "tg:bot:a%3Ab"},
+		{explicit: "chat42", want: "sdk:default:chat42"},
+	}
+	for _, tt := range tests {
+		if got := PinChatKeyFor(tt.explicit, chat); got != tt.want {
+			t.Errorf("PinChatKeyFor(%q) = %q, want %q", tt.explicit, got, tt.want)
+		}
+	}
+}
+
+// recordingMainRuntime 记录收到的请求，流式调用直接结束
+type recordingMainRuntime struct {
+	mu   sync.Mutex
+	reqs []MainRunRequest
+}
+
+func (r *recordingMainRuntime) Run(_ context.Context, req MainRunRequest) (*MainRunResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reqs = append(r.reqs, req)
+	return &MainRunResult{Output: "ok"}, nil
+}
+
+func (r *recordingMainRuntime) RunStream(_ context.Context, req MainRunRequest) (<-chan StreamEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reqs = append(r.reqs, req)
+	ch := make(chan StreamEvent)
+	close(ch)
+	return ch, nil
+}
+
+func (r *recordingMainRuntime) Close() error { return nil }
+
+func TestRunStreamPinsFollowChatAcrossSessions(t *testing.T) {
+	workspace := t.TempDir()
+	sessionMgr, err := session.NewManager(filepath.Join(workspace, "sessions"))
+	if err != nil {
+		t.Fatalf("session.NewManager: %v", err)
+	}
+	runtime := &recordingMainRuntime{}
+	mgr := NewAgentManager(&NewAgentManagerConfig{
+		SessionMgr:  sessionMgr,
+		Tools:       NewToolRegistry(),
+		DataDir:     workspace,
+		Workspace:   workspace,
+		MainRuntime: runtime,
+	})
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			List: []config.AgentConfig{{ID: "main", Default: true, Workspace: workspace}},
+		},
+	}
+	builder := NewContextBuilder(NewMemoryStore(workspace, nil, "", 0, false), workspace)
+	if err := mgr.SetupFromConfig(cfg, builder); err != nil {
+		t.Fatalf("SetupFromConfig: %v", err)
+	}
+
+	// 通过显式的两段式会话键置顶
+	reply, err := mgr.RunStream(context.Background(), &bus.InboundMessage{
+		Channel:   "sdk",
+		Content:   "/pin deploy from main",
+		Timestamp: time.Now(),
+	}, StreamRunOptions{ExplicitSessionKey: "sdk:app"})
+	if err != nil || !strings.Contains(reply, "#1") {
+		t.Fatalf("/pin reply = %q, %v", reply, err)
+	}
+
+	// 默认 chat 每次生成新会话，置顶笔记仍然可见
+	var sessionKeys []string
+	for i := 0; i < 2; i++ {
+		if _, err := mgr.RunStream(context.Background(), &bus.InboundMessage{
+			Channel:   "sdk",
+			AccountID: "app",
+			Content:   "which branch do we deploy?",
+			Timestamp: time.Now(),
+		}, StreamRunOptions{}); err != nil {
+			t.Fatalf("RunStream: %v", err)
+		}
+	}
+
+	if len(runtime.reqs) != 2 {
+		t.Fatalf("expected 2 runtime requests, got %d", len(runtime.reqs))
+	}
+	for _, req := range runtime.reqs {
+		sessionKeys = append(sessionKeys, req.SessionKey)
+		if !strings.Contains(req.PinnedContext, "1. deploy from main") {
+			t.Errorf("session %s did not receive the chat's pins: %q", req.SessionKey, req.PinnedContext)
+		}
+		if strings.Contains(req.Prompt, "deploy from main") {
+			t.Errorf("pins must not be folded into the stored prompt: %q", req.Prompt)
+		}
+	}
+	if sessionKeys[0] == sessionKeys[1] || sessionKeys[0] == "sdk:app:default" {
+		t.Fatalf("expected two rotated sessions, got %v", sessionKeys)
+	}
+}
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/smallnest/goclaw/agent/tools"
+	"github.com/smallnest/goclaw/config"
+)
+
+var errPinsReadOnly = fmt.Errorf("%w: pinned notes cannot be changed", tools.ErrReadOnly)
+
+// ReadOnlyToolMode maps the read_only config section onto a tool registry mode.
+func ReadOnlyToolMode(cfg *config.Config) tools.ReadOnlyMode {
+	if cfg == nil || !cfg.ReadOnly.Enabled {
+		return tools.ReadOnlyOff
+	}
+	if strings.EqualFold(strings.TrimSpace(cfg.ReadOnly.MutatingTools), string(tools.ReadOnlyError)) {
+		return tools.ReadOnlyError
+	}
+	return tools.ReadOnlyHide
+}
+
+// ApplyReadOnly puts the tool registry and the pin store into read-only mode
+// when cfg enables it. Entry points call it once after building both.
+func ApplyReadOnly(cfg *config.Config, registry *ToolRegistry, contextBuilder *ContextBuilder) {
+	mode := ReadOnlyToolMode(cfg)
+	if registry != nil {
+		registry.SetReadOnly(mode)
+	}
+	if contextBuilder != nil && contextBuilder.Pins() != nil {
+		contextBuilder.Pins().SetReadOnly(mode != tools.ReadOnlyOff)
+	}
+}
@@ -9,4 +9,7 @@ const (
 	CtxChannel    CtxKey = "goclaw.channel"
 	CtxAccountID  CtxKey = "goclaw.account_id"
 	CtxChatID     CtxKey = "goclaw.chat_id"
+
+	// CtxSubagentSpawnProvider 携带路由本次运行的 AgentManager（tools.SubagentSpawnProvider）
+	CtxSubagentSpawnProvider CtxKey = "goclaw.subagent_spawn_provider"
 )
@@ -1,13 +1,100 @@
 package agent
 
 import (
-	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/smallnest/goclaw/internal/sessionkey"
 )
 
+const (
+	// sessionKeySep separates the components of a session key.
+	sessionKeySep = sessionkey.Sep
+	// defaultSessionPart fills empty account and chat components.
+	defaultSessionPart = "default"
+)
+
+// SessionRef identifies the chat a session belongs to.
+//
+// Its canonical string form is "<channel>:<account>:<chat>". Inside each
+// component "%" is written as "%25" and ":" as "%3A", so components may
+// contain the separator without shifting the fields that follow.
+type SessionRef struct {
+	Channel   string
+	AccountID string
+	ChatID    string
+}
+
+// ParseSessionRef parses a session key. It never fails; the rules are:
+//
+//   - the key and every component are trimmed of surrounding whitespace;
+//   - "%25" and "%3A" (or "%3a") decode to "%" and ":", any other "%" is literal;
+//   - one component is a bare chat ID: "chat" → {"", "", "chat"};
+//   - two components are channel and account: "tg:bot" → {"tg", "bot", ""};
+//   - from three components on, everything after the second separator is the
+//     chat ID, so legacy keys with a raw ":" in the chat ID keep their channel
+//     and account: "tg:bot:a:b" → {"tg", "bot", "a:b"}.
+//
+// Missing components are left empty; use WithDefaults to fill them.
+// For every ref, ParseSessionRef(ref.String()) equals ref with its components trimmed.
+func ParseSessionRef(key string) SessionRef {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return SessionRef{}
+	}
+
+	parts := strings.SplitN(key, sessionKeySep, 3)
+	switch len(parts) {
+	case 1:
+		return SessionRef{ChatID: sessionkey.UnescapePart(parts[0])}
+	case 2:
+		return SessionRef{
+			Channel:   sessionkey.UnescapePart(parts[0]),
+			AccountID: sessionkey.UnescapePart(parts[1]),
+		}
+	default:
+		return SessionRef{
+			Channel:   sessionkey.UnescapePart(parts[0]),
+			AccountID: sessionkey.UnescapePart(parts[1]),
+			ChatID:    sessionkey.UnescapePart(parts[2]),
+		}
+	}
+}
+
+// LegacySessionKey returns key with every component unescaped, i.e. the form
+// keys had before components were escaped. Stores use it to find data written
+// by older versions; it equals the trimmed key when nothing was escaped.
+func LegacySessionKey(key string) string {
+	return sessionkey.Legacy(key)
+}
+
+// String returns the canonical session key of r. Components are trimmed and
+// escaped but not defaulted, so call WithDefaults first when routing.
+func (r SessionRef) String() string {
+	return sessionkey.EscapePart(r.Channel) + sessionKeySep +
+		sessionkey.EscapePart(r.AccountID) + sessionKeySep +
+		sessionkey.EscapePart(r.ChatID)
+}
+
+// WithDefaults returns r with trimmed components, an empty channel replaced by
+// channel and empty account and chat IDs replaced by "default".
+func (r SessionRef) WithDefaults(channel string) SessionRef {
+	r.Channel = strings.TrimSpace(r.Channel)
+	r.AccountID = strings.TrimSpace(r.AccountID)
+	r.ChatID = strings.TrimSpace(r.ChatID)
+	if r.Channel == "" {
+		r.Channel = strings.TrimSpace(channel)
+	}
+	if r.AccountID == "" {
+		r.AccountID = defaultSessionPart
+	}
+	if r.ChatID == "" {
+		r.ChatID = defaultSessionPart
+	}
+	return r
+}
+
 // SessionKeyOptions controls how a session key is generated.
 type SessionKeyOptions struct {
 	Explicit       string
@@ -19,31 +106,24 @@ type SessionKeyOptions struct {
 }
 
 // ResolveSessionKey generates a normalized session key and reports whether it is a fresh key.
+// Explicit keys are returned as given (trimmed); generated keys use the SessionRef form.
 func ResolveSessionKey(opts SessionKeyOptions) (string, bool) {
 	explicit := strings.TrimSpace(opts.Explicit)
 	if explicit != "" {
 		return explicit, false
 	}
 
-	channel := strings.TrimSpace(opts.Channel)
-	if channel == "" {
-		channel = "cli"
-	}
-
-	accountID := strings.TrimSpace(opts.AccountID)
-	if accountID == "" {
-		accountID = "default"
-	}
-
-	chatID := strings.TrimSpace(opts.ChatID)
-	if chatID == "" {
-		chatID = "default"
-	}
+	ref := SessionRef{
+		Channel:   opts.Channel,
+		AccountID: opts.AccountID,
+		ChatID:    opts.ChatID,
+	}.WithDefaults("cli")
 
-	if opts.FreshOnDefault && strings.EqualFold(chatID, "default") {
+	if opts.FreshOnDefault && strings.EqualFold(ref.ChatID, defaultSessionPart) {
 		// Fresh keys must be unique even under rapid creation. Avoid Unix() second resolution.
-		return fmt.Sprintf("%s:%s:%s", channel, accountID, uuid.NewString()), true
+		ref.ChatID = uuid.NewString()
+		return ref.String(), true
 	}
 
-	return fmt.Sprintf("%s:%s:%s", channel, accountID, chatID), false
+	return ref.String(), false
 }
@@ -1,6 +1,11 @@
 package agent
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/smallnest/goclaw/internal/sessionkey"
+)
 
 func TestResolveSessionKeyFreshOnDefaultGeneratesUniqueKeys(t *testing.T) {
 	seen := map[string]struct{}{}
@@ -22,3 +27,232 @@ func TestResolveSessionKeyFreshOnDefaultGeneratesUniqueKeys(t *testing.T) {
 		t.Fatalf("expected unique keys for fresh sessions, got %d unique out of 50", len(seen))
 	}
 }
+
+func TestParseSessionRef(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want SessionRef
+	}{
+		{"full triple", "telegram:bot1:chat42", SessionRef{"telegram", "bot1", "chat42"}},
+		{"channel and account", "cli:default", SessionRef{"cli", "default", ""}},
+		{"bare chat", "standalone-chat", SessionRef{"", "", "standalone-chat"}},
+		{"empty", "", SessionRef{}},
+		{"only whitespace", "  \t ", SessionRef{}},
+		{"trims components", " qq : acct : g1 ", SessionRef{"qq", "acct", "g1"}},
+		{"empty parts", "::", SessionRef{}},
+		{"empty account", "qq::g1", SessionRef{"qq", "", "g1"}},
+		{"legacy raw separator in chat", "telegram:bot1:thread:7", SessionRef{"telegram", "bot1", "thread:7"}},
+		{"escaped separator in chat", "telegram:bot1:thread%3A7", SessionRef{"telegram", "bot1", "thread:7"}},
+		{"escaped separator in account", "slack:team%3Aeu:C01", SessionRef{"slack", "team:eu", "C01"}},
+		{"lowercase escape", "a:b%3ac:d", SessionRef{"a", "b:c", "d"}},
+		{"escaped percent", "a:b:100%25", SessionRef{"a", "b", "100%"}},
+		{"unknown escape is literal", "a:b:50%off%2", SessionRef{"a", "b", "50%off%2"}},
+		{"unicode", "飞书:账号:群聊🙂", SessionRef{"飞书", "账号", "群聊🙂"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSessionRef(tt.in); got != tt.want {
+				t.Fatalf("ParseSessionRef(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionRefWithDefaults(t *testing.T) {
+	tests := []struct {
+		in   string
+		want SessionRef
+	}{
+		{"telegram:bot1:chat42", SessionRef{"telegram", "bot1", "chat42"}},
+		{"cli:default", SessionRef{"cli", "default", "default"}},
+		{"standalone-chat", SessionRef{"cli", "default", "standalone-chat"}},
+		{"", SessionRef{"cli", "default", "default"}},
+		{"::", SessionRef{"cli", "default", "default"}},
+	}
+	for _, tt := range tests {
+		if got := ParseSessionRef(tt.in).WithDefaults("cli"); got != tt.want {
+			t.Fatalf("ParseSessionRef(%q).WithDefaults = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSessionRefString(t *testing.T) {
+	tests := []struct {
+		ref  SessionRef
+		want string
+	}{
+		{SessionRef{"telegram", "bot1", "chat42"}, "telegram:bot1:chat42"},
+		{SessionRef{"telegram", "bot1", "thread:7"}, "telegram:bot1:thread%3A7"},
+		{SessionRef{"a:b", "c", "d"}, "a%3Ab:c:d"},
+		{SessionRef{"a", "b", "100%"}, "a:b:100%25"},
+		{SessionRef{" a ", "", ""}, "a::"},
+		{SessionRef{}, "::"},
+	}
+	for _, tt := range tests {
+		if got := tt.ref.String(); got != tt.want {
+			t.Fatalf("%+v.String() = %q, want %q", tt.ref, got, tt.want)
+		}
+		if back := ParseSessionRef(tt.ref.String()); back != trimRef(tt.ref) {
+			t.Fatalf("round trip of %+v = %+v", tt.ref, back)
+		}
+	}
+}
+
+func TestResolveSessionKeyEscapesComponents(t *testing.T) {
+	key, fresh := ResolveSessionKey(SessionKeyOptions{
+		Channel:   "telegram",
+		AccountID: "bot1",
+		ChatID:    "thread:7",
+	})
+	if fresh || key != "telegram:bot1:thread%3A7" {
+		t.Fatalf("ResolveSessionKey = %q, %v", key, fresh)
+	}
+	if ref := ParseSessionRef(key); ref != (SessionRef{"telegram", "bot1", "thread:7"}) {
+		t.Fatalf("resolved key does not parse back: %+v", ref)
+	}
+
+	if key, _ := ResolveSessionKey(SessionKeyOptions{Explicit: " custom:key:a:b "}); key != "custom:key:a:b" {
+		t.Fatalf("explicit keys must be kept verbatim, got %q", key)
+	}
+}
+
+func trimRef(r SessionRef) SessionRef {
+	return SessionRef{
+		Channel:   strings.TrimSpace(r.Channel),
+		AccountID: strings.TrimSpace(r.AccountID),
+		ChatID:    strings.TrimSpace(r.ChatID),
+	}
+}
+
+func FuzzSessionRefRoundTrip(f *testing.F) {
+	for _, seed := range [][3]string{
+		{"telegram", "bot1", "chat42"},
+		{"", "", ""},
+		{"a:b", "c%3A", "d:e:f"},
+		{" 飞书 ", "账号", "🙂:%"},
+		{"%", "%25", "%3a"},
+	} {
+		f.Add(seed[0], seed[1], seed[2])
+	}
+
+	f.Fuzz(func(t *testing.T, channel, accountID, chatID string) {
+		ref := SessionRef{Channel: channel, AccountID: accountID, ChatID: chatID}
+		key := ref.String()
+		if n := strings.Count(key, sessionKeySep); n != 2 {
+			t.Fatalf("%+v.String() = %q has %d separators, want 2", ref, key, n)
+		}
+		if got := ParseSessionRef(key); got != trimRef(ref) {
+			t.Fatalf("ParseSessionRef(%q) = %+v, want %+v", key, got, trimRef(ref))
+		}
+	})
+}
+
+func FuzzParseSessionRef(f *testing.F) {
+	for _, seed := range []string{
+		"", ":", "::", "telegram:bot1:chat42", "cli:default", "standalone",
+		"a:b:c:d", "a%3Ab:c:d%25", "%", "%3", "%%3A", " x : y : z ", "飞书:账号:群聊",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, key string) {
+		ref := ParseSessionRef(key)
+		// The canonical form is a fixed point.
+		canonical := ref.String()
+		if again := ParseSessionRef(canonical); again != ref {
+			t.Fatalf("ParseSessionRef(%q) = %+v, but its canonical form %q parses to %+v", key, ref, canonical, again)
+		}
+		if again := ParseSessionRef(canonical).String(); again != canonical {
+			t.Fatalf("canonical form of %q is not stable: %q then %q", key, canonical, again)
+		}
+
+		routed := ref.WithDefaults("cli")
+		if routed.Channel == "" || routed.AccountID == "" || routed.ChatID == "" {
+			t.Fatalf("WithDefaults left an empty component: %+v", routed)
+		}
+		// A key without separators can only name a chat.
+		if !strings.Contains(key, sessionKeySep) && (ref.Channel != "" || ref.AccountID != "") {
+			t.Fatalf("ParseSessionRef(%q) = %+v: bare key must not set channel or account", key, ref)
+		}
+	})
+}
+
+func TestParseAgentSessionKey(t *testing.T) {
+	tests := []struct {
+		key        string
+		agentID    string
+		subagentID string
+		isSubagent bool
+	}{
+		{key: "", isSubagent: false},
+		{key: "agent:main:subagent:abc", agentID: "main", subagentID: "abc", isSubagent: true},
+		{key: " agent : main : subagent : abc ", agentID: "main", subagentID: "abc", isSubagent: true},
+		{key: "subagent:abc", subagentID: "abc", isSubagent: true},
+		{key: "agent:main:cli:default", agentID: "main"},
+		{key: "agent:main", agentID: "main"},
+		// 空组件保留在原位，不会让后续组件前移
+		{key: "agent::subagent:abc", agentID: "", subagentID: "abc", isSubagent: true},
+		{key: "agent:::x", agentID: ""},
+		// 转义的 ":" 属于组件内容
+		{key: "agent:team%3Aa:subagent:abc", agentID: "team:a", subagentID: "abc", isSubagent: true},
+		{key: "agent:main%25:x", agentID: "main%"},
+		{key: "telegram:bot:my%3Asubagent%3Ax", isSubagent: false},
+		{key: "telegram:bot:subagent:x", isSubagent: true},
+		{key: "telegram:bot1:chat42", isSubagent: false},
+	}
+	for _, tt := range tests {
+		agentID, subagentID, isSubagent := ParseAgentSessionKey(tt.key)
+		if agentID != tt.agentID || subagentID != tt.subagentID || isSubagent != tt.isSubagent {
+			t.Errorf("ParseAgentSessionKey(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.key, agentID, subagentID, isSubagent, tt.agentID, tt.subagentID, tt.isSubagent)
+		}
+		if got := IsSubagentSessionKey(tt.key); got != tt.isSubagent {
+			t.Errorf("IsSubagentSessionKey(%q) = %v, want %v", tt.key, got, tt.isSubagent)
+		}
+	}
+}
+
+func FuzzParseAgentSessionKey(f *testing.F) {
+	for _, seed := range [][2]string{
+		{"main", "abc"},
+		{"", ""},
+		{"team:a", "x:y"},
+		{" 分身 ", "%25"},
+		{"%3A", "subagent"},
+	} {
+		f.Add(seed[0], seed[1])
+	}
+
+	f.Fuzz(func(t *testing.T, agentID, subagentID string) {
+		key := "agent:" + sessionkey.EscapePart(agentID) + ":subagent:" + sessionkey.EscapePart(subagentID)
+		gotAgent, gotSub, isSubagent := ParseAgentSessionKey(key)
+		if !isSubagent || gotAgent != strings.TrimSpace(agentID) || gotSub != strings.TrimSpace(subagentID) {
+			t.Fatalf("ParseAgentSessionKey(%q) = %q, %q, %v; want %q, %q, true",
+				key, gotAgent, gotSub, isSubagent, strings.TrimSpace(agentID), strings.TrimSpace(subagentID))
+		}
+		// agentId 与 ParseSessionRef 读到的 account 组件一致
+		if ref := ParseSessionRef(key); ref.Channel != "agent" || ref.AccountID != gotAgent {
+			t.Fatalf("ParseSessionRef(%q) = %+v disagrees with agent ID %q", key, ref, gotAgent)
+		}
+
+		// 同样的组件作为普通 agent 会话
+		plain := "agent:" + sessionkey.EscapePart(agentID) + ":" + sessionkey.EscapePart(subagentID)
+		if got, _, _ := ParseAgentSessionKey(plain); got != ParseSessionRef(plain).AccountID {
+			t.Fatalf("ParseAgentSessionKey(%q) agent ID %q, ParseSessionRef account %q", plain, got, ParseSessionRef(plain).AccountID)
+		}
+	})
+}
+
+func TestLegacySessionKey(t *testing.T) {
+	for key, want := range map[string]string{
+		"telegram:bot:group%3A42%25": "telegram:bot:group:42%",
+		" tg:bot:chat ":              "tg:bot:chat",
+		"tg::chat%3a1":               "tg::chat:1",
+	} {
+		if got := LegacySessionKey(key); got != want {
+			t.Errorf("LegacySessionKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -22,6 +22,7 @@ type SubagentAnnounceParams struct {
 	ChildSessionKey     string
 	ChildRunID          string
 	RequesterSessionKey string
+	Requester           SessionRef // 宣告目标；为空时由 RequesterSessionKey 解析
 	RequesterOrigin     *DeliveryContext
 	RequesterDisplayKey string
 	Task                string
@@ -35,7 +36,7 @@ type SubagentAnnounceParams struct {
 }
 
 // AnnounceCallback 宣告回调
-type AnnounceCallback func(sessionKey, message string) error
+type AnnounceCallback func(requester SessionRef, message string) error
 
 // SubagentAnnouncer 分身宣告器
 type SubagentAnnouncer struct {
@@ -102,7 +103,11 @@ You can respond with NO_REPLY if no announcement is needed (e.g., internal task
 		announceType, taskLabel, statusLabel, findings, statsLine, announceType)
 
 	// 发送宣告到主 Agent
-	if err := a.onAnnounce(params.RequesterSessionKey, triggerMessage); err != nil {
+	requester := params.Requester
+	if requester == (SessionRef{}) {
+		requester = ParseSessionRef(params.RequesterSessionKey)
+	}
+	if err := a.onAnnounce(requester, triggerMessage); err != nil {
 		logger.Error("Failed to announce subagent result",
 			zap.String("run_id", params.ChildRunID),
 			zap.Error(err))
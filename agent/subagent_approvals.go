@@ -53,7 +53,7 @@ func (m *AgentManager) decideSubagentPermissionByMainAgent(ctx context.Context,
 	approvalSessionKey := fmt.Sprintf("%s:approvals:%s", requesterSessionKey, strings.TrimSpace(run.RunID))
 
 	prompt := buildSubagentApprovalPrompt(run, req)
-	resp, err := m.mainRuntime.Run(ctx, MainRunRequest{
+	resp, err := m.mainRuntime.Run(m.RunContext(ctx), MainRunRequest{
 		AgentID:       strings.TrimSpace(approverAgentID),
 		SessionKey:    approvalSessionKey,
 		Prompt:        prompt,
@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/smallnest/goclaw/internal/logger"
+	"github.com/smallnest/goclaw/internal/sessionkey"
 	"go.uber.org/zap"
 )
 
@@ -52,6 +53,11 @@ type SubagentRunRecord struct {
 	CleanupHandled      bool                `json:"cleanup_handled"`
 }
 
+// RequesterRef 返回请求者会话的 SessionRef，宣告结果时据此路由
+func (r *SubagentRunRecord) RequesterRef() SessionRef {
+	return ParseSessionRef(r.RequesterSessionKey)
+}
+
 // SubagentRegistry 分身注册表
 type SubagentRegistry struct {
 	runs        map[string]*SubagentRunRecord
@@ -162,8 +168,10 @@ func (r *SubagentRegistry) ListRunsForRequester(requesterSessionKey string) []*S
 		return result
 	}
 
+	// 旧版本记录的请求者 key 未转义 chat ID 中的 ":" 和 "%"
+	legacy := LegacySessionKey(key)
 	for _, record := range r.runs {
-		if record.RequesterSessionKey == key {
+		if record.RequesterSessionKey == key || record.RequesterSessionKey == legacy {
 			result = append(result, record)
 		}
 	}
@@ -406,23 +414,8 @@ func GenerateRunID() string {
 
 // IsSubagentSessionKey 判断是否为分身会话密钥
 func IsSubagentSessionKey(sessionKey string) bool {
-	// 分身会话格式: agent:<agentId>:subagent:<uuid>
-	// 或: subagent:<uuid>
-	if sessionKey == "" {
-		return false
-	}
-	return containsSubagentMarker(sessionKey)
-}
-
-// containsSubagentMarker 检查是否包含分身标记
-func containsSubagentMarker(s string) bool {
-	marker := ":subagent:"
-	for i := 0; i <= len(s)-len(marker); i++ {
-		if s[i:i+len(marker)] == marker {
-			return true
-		}
-	}
-	return false
+	_, _, isSubagent := ParseAgentSessionKey(sessionKey)
+	return isSubagent
 }
 
 // GenerateChildSessionKey 生成子会话密钥
@@ -432,62 +425,39 @@ func GenerateChildSessionKey(agentID string) string {
 }
 
 // ParseAgentSessionKey 解析 Agent 会话密钥
+//
+// 支持的格式：
+//   - agent:<agentId>:subagent:<uuid>
+//   - subagent:<uuid>
+//   - agent:<agentId>[:<rest>]
+//
+// 组件按 ParseSessionRef 的规则切分：保留空组件，并解码 "%3A"/"%25"，
+// 因此 "agent::subagent:x" 的 agentId 为空，组件内转义的 ":" 不会被当作分隔符。
+// 其他位置出现 "subagent" 组件的键同样视为分身会话，但不解析分身 ID。
 func ParseAgentSessionKey(sessionKey string) (agentID string, subagentID string, isSubagent bool) {
-	if sessionKey == "" {
+	parts := sessionkey.SplitParts(sessionKey)
+	if len(parts) < 2 {
 		return "", "", false
 	}
 
-	// 检查是否为分身会话
-	if idx := findSubagentMarkerIndex(sessionKey); idx >= 0 {
-		// 格式: agent:<agentId>:subagent:<uuid>
-		parts := splitSessionKey(sessionKey)
-		if len(parts) >= 4 && parts[0] == "agent" && parts[2] == "subagent" {
-			return parts[1], parts[3], true
-		}
-		// 格式: subagent:<uuid>
-		if len(parts) >= 2 && parts[0] == "subagent" {
-			return "", parts[1], true
+	switch parts[0] {
+	case "agent":
+		if len(parts) >= 4 && parts[2] == "subagent" {
+			return parts[1], strings.Join(parts[3:], sessionKeySep), true
 		}
-		return "", "", true
+		return parts[1], "", hasSubagentMarker(parts[2:])
+	case "subagent":
+		return "", strings.Join(parts[1:], sessionKeySep), true
 	}
-
-	// 格式: agent:<agentId>:<sessionKey>
-	parts := splitSessionKey(sessionKey)
-	if len(parts) >= 2 && parts[0] == "agent" {
-		return parts[1], "", false
-	}
-
-	return "", "", false
-}
-
-// findSubagentMarkerIndex 查找分身标记位置
-func findSubagentMarkerIndex(s string) int {
-	marker := ":subagent:"
-	for i := 0; i <= len(s)-len(marker); i++ {
-		if s[i:i+len(marker)] == marker {
-			return i
-		}
-	}
-	return -1
+	return "", "", hasSubagentMarker(parts[1:])
 }
 
-// splitSessionKey 分割会话密钥
-func splitSessionKey(s string) []string {
-	var parts []string
-	var current strings.Builder
-
-	for i := 0; i < len(s); i++ {
-		if s[i] == ':' {
-			if current.Len() > 0 {
-				parts = append(parts, current.String())
-				current.Reset()
-			}
-		} else {
-			current.WriteByte(s[i])
+// hasSubagentMarker 判断是否有后面还跟着组件的 "subagent" 组件
+func hasSubagentMarker(parts []string) bool {
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "subagent" {
+			return true
 		}
 	}
-	if current.Len() > 0 {
-		parts = append(parts, current.String())
-	}
-	return parts
+	return false
 }
@@ -24,6 +24,12 @@ func (r *ToolRegistry) RegisterExisting(tool tools.Tool) error {
 	return r.registry.Register(tool)
 }
 
+// RegisterExistingIfAbsent registers tool unless a tool with the same name is
+// already present, and reports whether it was added
+func (r *ToolRegistry) RegisterExistingIfAbsent(tool tools.Tool) bool {
+	return r.registry.RegisterIfAbsent(tool)
+}
+
 // Unregister removes a tool
 func (r *ToolRegistry) Unregister(name string) {
 	r.registry.Unregister(name)
@@ -54,6 +60,16 @@ func (r *ToolRegistry) Clear() {
 	r.registry.Clear()
 }
 
+// SetReadOnly hides or blocks mutating tools according to mode
+func (r *ToolRegistry) SetReadOnly(mode tools.ReadOnlyMode) {
+	r.registry.SetReadOnly(mode)
+}
+
+// ReadOnly returns the current read-only mode
+func (r *ToolRegistry) ReadOnly() tools.ReadOnlyMode {
+	return r.registry.ReadOnly()
+}
+
 // Execute executes a tool using the existing registry
 func (r *ToolRegistry) Execute(ctx context.Context, name string, params map[string]interface{}) (string, error) {
 	return r.registry.Execute(ctx, name, params)
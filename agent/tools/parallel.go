@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/smallnest/goclaw/internal/keylock"
+	"github.com/smallnest/goclaw/internal/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultMaxParallelTools is the worker limit used when BatchOptions leaves it unset.
+const DefaultMaxParallelTools = 4
+
+// SerialTool is implemented by tools whose calls must not overlap. Calls of
+// one Dispatcher that return the same non-empty key run one at a time; within
+// a batch they keep their call order.
+type SerialTool interface {
+	SerialKey(params map[string]interface{}) string
+}
+
+// BatchOptions controls a Dispatcher.
+type BatchOptions struct {
+	// MaxParallel limits concurrently running calls. <= 0 uses
+	// DefaultMaxParallelTools; 1 runs the calls sequentially.
+	MaxParallel int
+	// CallTimeout bounds each call individually. Zero means the run context's
+	// deadline is the only limit.
+	CallTimeout time.Duration
+	// Workspace resolves relative file paths in serial keys, so "a.txt" and
+	// "<workspace>/a.txt" serialize together. Empty uses the working directory.
+	Workspace string
+}
+
+// ToolCallResult is the outcome of one call in a batch.
+type ToolCallResult struct {
+	ID        string
+	Name      string
+	Output    string
+	Err       error
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// sharedLocks serializes calls on resources the whole process shares: the
+// one CDP browser session and files on disk. Other serial keys only order
+// the calls of one Dispatcher, since e.g. every exec call runs its own shell.
+var sharedLocks = keylock.New()
+
+func isSharedSerialKey(key string) bool {
+	return key == "browser" || key == "mcp-config" ||
+		strings.HasPrefix(key, "path:") || strings.HasPrefix(key, "config:")
+}
+
+// SerialKey returns the serialization group of a call, or "" when it may run
+// concurrently with anything. Relative file paths are resolved against
+// workspace (the working directory when empty).
+func SerialKey(tool Tool, params map[string]interface{}, workspace string) string {
+	if st, ok := tool.(SerialTool); ok {
+		return st.SerialKey(params)
+	}
+	name := tool.Name()
+	switch {
+	case name == "exec":
+		return "shell"
+	case strings.HasPrefix(name, "browser_"):
+		// All browser tools drive the same CDP session.
+		return "browser"
+	case name == "write_file", name == "edit_file":
+		if p := pathParam(params, "path", workspace); p != "" {
+			return "path:" + p
+		}
+		return "fs-write"
+	case name == "update_config":
+		file, _ := params["file"].(string)
+		return "config:" + strings.TrimSpace(file)
+	case name == "mcp_put_server", name == "mcp_delete_server", name == "mcp_set_enabled":
+		return "mcp-config"
+	}
+	return ""
+}
+
+// readPathKey returns the path group a read-only file call joins when the
+// same batch also writes that path, so it observes the write.
+func readPathKey(tool Tool, params map[string]interface{}, workspace string) string {
+	switch tool.Name() {
+	case "read_file", "list_dir":
+		if p := pathParam(params, "path", workspace); p != "" {
+			return "path:" + p
+		}
+	}
+	return ""
+}
+
+// pathParam returns params[key] as a clean absolute path, resolving relative
+// paths against workspace.
+func pathParam(params map[string]interface{}, key, workspace string) string {
+	p, _ := params[key].(string)
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return ""
+	}
+	if !filepath.IsAbs(p) && strings.TrimSpace(workspace) != "" {
+		p = filepath.Join(strings.TrimSpace(workspace), p)
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return filepath.Clean(p)
+}
+
+// Dispatcher runs the tool calls of one agent run: at most MaxParallel
+// calls at once, calls sharing a serial key one at a time in arrival order,
+// and each call under CallTimeout. Create one per run, so the limit and the
+// run-scoped serial keys never couple separate sessions.
+type Dispatcher struct {
+	opts   BatchOptions
+	sem    chan struct{}
+	serial *keylock.Locker
+}
+
+// NewDispatcher creates a Dispatcher; opts.MaxParallel <= 0 uses DefaultMaxParallelTools.
+func NewDispatcher(opts BatchOptions) *Dispatcher {
+	workers := opts.MaxParallel
+	if workers <= 0 {
+		workers = DefaultMaxParallelTools
+	}
+	return &Dispatcher{opts: opts, sem: make(chan struct{}, workers), serial: keylock.New()}
+}
+
+// Execute runs a single call of tool with params.
+func (d *Dispatcher) Execute(ctx context.Context, tool Tool, params map[string]interface{}) (string, error) {
+	call := ToolCall{Name: tool.Name(), Params: params}
+	result := d.call(ctx, call, tool, SerialKey(tool, params, d.opts.Workspace))
+	return result.Output, result.Err
+}
+
+// PendingCall is a call started by Dispatcher.Start.
+type PendingCall struct {
+	done   chan struct{}
+	result ToolCallResult
+}
+
+// Done is closed once the call has finished.
+func (p *PendingCall) Done() <-chan struct{} {
+	return p.done
+}
+
+// Result waits for the call and returns its result.
+func (p *PendingCall) Result() ToolCallResult {
+	<-p.done
+	return p.result
+}
+
+// Start starts the tool calls of one model turn and returns their pending
+// results in call order. Independent calls run concurrently; calls sharing a
+// serial key are grouped and run in call order. A failing call does not
+// affect its siblings; only cancelling ctx stops calls that have not finished.
+// lookup resolves call names, typically Registry.Get.
+func (d *Dispatcher) Start(ctx context.Context, calls []ToolCall, lookup func(name string) (Tool, bool)) []*PendingCall {
+	pending := make([]*PendingCall, len(calls))
+	resolved := make([]Tool, len(calls))
+	keys := make([]string, len(calls))
+	for i, call := range calls {
+		pending[i] = &PendingCall{done: make(chan struct{})}
+		if tool, ok := lookup(call.Name); ok {
+			resolved[i] = tool
+			keys[i] = SerialKey(tool, call.Params, d.opts.Workspace)
+		}
+	}
+	for i, call := range calls {
+		if keys[i] != "" || resolved[i] == nil {
+			continue
+		}
+		if key := readPathKey(resolved[i], call.Params, d.opts.Workspace); key != "" && containsKey(keys, key) {
+			keys[i] = key
+		}
+	}
+
+	// Plan units of work: each serial group is one unit (its calls stay in
+	// order), every other call is a unit of its own.
+	var units [][]int
+	groupUnit := make(map[string]int)
+	for i := range calls {
+		if keys[i] == "" {
+			units = append(units, []int{i})
+			continue
+		}
+		if u, ok := groupUnit[keys[i]]; ok {
+			units[u] = append(units[u], i)
+			continue
+		}
+		groupUnit[keys[i]] = len(units)
+		units = append(units, []int{i})
+	}
+
+	for _, unit := range units {
+		go func(unit []int) {
+			for _, i := range unit {
+				p := pending[i]
+				if resolved[i] == nil {
+					p.result = ToolCallResult{ID: calls[i].ID, Name: calls[i].Name, Err: fmt.Errorf("tool %s not found", calls[i].Name)}
+				} else {
+					p.result = d.call(ctx, calls[i], resolved[i], keys[i])
+				}
+				close(p.done)
+			}
+		}(unit)
+	}
+	return pending
+}
+
+// ExecuteBatch runs calls like Start and waits for all of them. Results are
+// in call order.
+func (d *Dispatcher) ExecuteBatch(ctx context.Context, calls []ToolCall, lookup func(name string) (Tool, bool)) []ToolCallResult {
+	results := make([]ToolCallResult, len(calls))
+	for i, p := range d.Start(ctx, calls, lookup) {
+		results[i] = p.Result()
+	}
+	return results
+}
+
+// call runs one call and records an audit entry. The serial lock is taken
+// before a worker slot, so calls queued behind a sibling do not hold slots
+// other calls need, and timing starts only once the call runs.
+func (d *Dispatcher) call(ctx context.Context, call ToolCall, tool Tool, serialKey string) ToolCallResult {
+	result := ToolCallResult{ID: call.ID, Name: call.Name}
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	if serialKey != "" {
+		locks := d.serial
+		if isSharedSerialKey(serialKey) {
+			locks = sharedLocks
+		}
+		unlock, err := locks.Lock(ctx, serialKey)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		defer unlock()
+	}
+
+	select {
+	case d.sem <- struct{}{}:
+		defer func() { <-d.sem }()
+	case <-ctx.Done():
+		result.Err = ctx.Err()
+		return result
+	}
+
+	result.StartedAt = time.Now()
+	result.Output, result.Err = runWithTimeout(ctx, call.Name, d.opts.CallTimeout, func(ctx context.Context) (string, error) {
+		return tool.Execute(ctx, call.Params)
+	})
+	result.Duration = time.Since(result.StartedAt)
+	logToolCall(result, serialKey)
+	return result
+}
+
+// runWithTimeout runs fn under its own call timeout and labels timeouts of
+// the call itself (not of the run context) with the tool name.
+func runWithTimeout(ctx context.Context, name string, timeout time.Duration, fn func(context.Context) (string, error)) (string, error) {
+	callCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	output, err := fn(callCtx)
+	if err != nil && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		err = fmt.Errorf("tool %s timed out after %s: %w", name, timeout, err)
+	}
+	return output, err
+}
+
+// logToolCall records the audit entry of a finished call.
+func logToolCall(result ToolCallResult, serialKey string) {
+	fields := []zap.Field{
+		zap.String("tool_call_id", result.ID),
+		zap.String("tool", result.Name),
+		zap.String("serial_key", serialKey),
+		zap.Time("started_at", result.StartedAt),
+		zap.Duration("duration", result.Duration),
+	}
+	if result.Err != nil {
+		logger.Warn("Tool call failed", append(fields, zap.Error(result.Err))...)
+	} else {
+		logger.Info("Tool call finished", fields...)
+	}
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,429 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowTool 模拟有固定延迟的工具，并记录并发度与调用顺序
+type slowTool struct {
+	name    string
+	delay   time.Duration
+	fail    map[string]bool
+	running int32
+	peak    int32
+	mu      sync.Mutex
+	order   []string
+}
+
+func (s *slowTool) Name() string        { return s.name }
+func (s *slowTool) Description() string { return "slow fake tool" }
+func (s *slowTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+func (s *slowTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	n := atomic.AddInt32(&s.running, 1)
+	defer atomic.AddInt32(&s.running, -1)
+	for {
+		p := atomic.LoadInt32(&s.peak)
+		if n <= p || atomic.CompareAndSwapInt32(&s.peak, p, n) {
+			break
+		}
+	}
+
+	arg, _ := params["arg"].(string)
+	s.mu.Lock()
+	s.order = append(s.order, arg)
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if s.fail[arg] {
+		return "", errors.New("boom " + arg)
+	}
+	return s.name + ":" + arg, nil
+}
+
+// serialSlowTool 所有调用共享同一个串行键
+type serialSlowTool struct {
+	slowTool
+}
+
+func (s *serialSlowTool) SerialKey(params map[string]interface{}) string { return "test-serial" }
+
+func newBatch(name string, n int) []ToolCall {
+	calls := make([]ToolCall, n)
+	for i := range calls {
+		calls[i] = ToolCall{
+			ID:     fmt.Sprintf("call_%d", i),
+			Name:   name,
+			Params: map[string]interface{}{"arg": fmt.Sprintf("%d", i)},
+		}
+	}
+	return calls
+}
+
+func TestExecuteBatchParallelLatency(t *testing.T) {
+	const (
+		calls = 6
+		delay = 50 * time.Millisecond
+	)
+	registry := NewRegistry()
+	tool := &slowTool{name: "slow", delay: delay}
+	_ = registry.Register(tool)
+
+	start := time.Now()
+	NewDispatcher(BatchOptions{MaxParallel: 1}).ExecuteBatch(context.Background(), newBatch("slow", calls), registry.Get)
+	serial := time.Since(start)
+
+	start = time.Now()
+	results := NewDispatcher(BatchOptions{MaxParallel: calls}).ExecuteBatch(context.Background(), newBatch("slow", calls), registry.Get)
+	parallel := time.Since(start)
+
+	for i, r := range results {
+		if r.Err != nil || r.Output != fmt.Sprintf("slow:%d", i) {
+			t.Fatalf("result %d = %+v", i, r)
+		}
+	}
+	if serial < calls*delay {
+		t.Fatalf("MaxParallel=1 should run sequentially, took %s", serial)
+	}
+	if parallel > serial/2 {
+		t.Fatalf("parallel batch took %s, serial %s; expected at least 2x speedup", parallel, serial)
+	}
+	t.Logf("%d calls of %s: serial %s, parallel %s", calls, delay, serial, parallel)
+}
+
+func TestExecuteBatchRespectsWorkerLimit(t *testing.T) {
+	registry := NewRegistry()
+	tool := &slowTool{name: "slow", delay: 20 * time.Millisecond}
+	_ = registry.Register(tool)
+
+	NewDispatcher(BatchOptions{MaxParallel: 3}).ExecuteBatch(context.Background(), newBatch("slow", 8), registry.Get)
+	if peak := atomic.LoadInt32(&tool.peak); peak > 3 || peak < 2 {
+		t.Fatalf("peak concurrency = %d, want 2..3", peak)
+	}
+}
+
+func TestExecuteBatchSerialToolsKeepOrder(t *testing.T) {
+	registry := NewRegistry()
+	serial := &serialSlowTool{slowTool{name: "serial", delay: 10 * time.Millisecond}}
+	_ = registry.Register(serial)
+
+	results := NewDispatcher(BatchOptions{MaxParallel: 5}).ExecuteBatch(context.Background(), newBatch("serial", 5), registry.Get)
+	if peak := atomic.LoadInt32(&serial.peak); peak != 1 {
+		t.Fatalf("serial tool ran with concurrency %d", peak)
+	}
+	want := []string{"0", "1", "2", "3", "4"}
+	for i, arg := range serial.order {
+		if arg != want[i] {
+			t.Fatalf("serial calls ran out of order: %v", serial.order)
+		}
+	}
+	for i, r := range results {
+		if r.ID != fmt.Sprintf("call_%d", i) {
+			t.Fatalf("results not in call order: %+v", results)
+		}
+	}
+}
+
+func TestExecuteBatchFailureIsolation(t *testing.T) {
+	registry := NewRegistry()
+	tool := &slowTool{name: "slow", delay: 10 * time.Millisecond, fail: map[string]bool{"1": true}}
+	_ = registry.Register(tool)
+
+	calls := append(newBatch("slow", 3), ToolCall{ID: "call_missing", Name: "missing"})
+	results := NewDispatcher(BatchOptions{}).ExecuteBatch(context.Background(), calls, registry.Get)
+
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Fatalf("sibling calls should succeed: %+v", results)
+	}
+	if results[1].Err == nil || results[1].Err.Error() != "boom 1" {
+		t.Fatalf("expected call 1 to fail, got %+v", results[1])
+	}
+	if results[3].Err == nil {
+		t.Fatalf("expected unknown tool to fail")
+	}
+}
+
+func TestExecuteBatchPerCallTimeout(t *testing.T) {
+	registry := NewRegistry()
+	_ = registry.Register(&slowTool{name: "slow", delay: 200 * time.Millisecond})
+	_ = registry.Register(&slowTool{name: "fast", delay: time.Millisecond})
+
+	calls := []ToolCall{
+		{ID: "a", Name: "slow", Params: map[string]interface{}{"arg": "a"}},
+		{ID: "b", Name: "fast", Params: map[string]interface{}{"arg": "b"}},
+	}
+	results := NewDispatcher(BatchOptions{CallTimeout: 30 * time.Millisecond}).ExecuteBatch(context.Background(), calls, registry.Get)
+
+	if !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Fatalf("expected slow call to time out, got %v", results[0].Err)
+	}
+	if results[0].Duration > 150*time.Millisecond {
+		t.Fatalf("timed out call duration %s not bounded by timeout", results[0].Duration)
+	}
+	if results[1].Err != nil || results[1].Output != "fast:b" {
+		t.Fatalf("fast call affected by sibling timeout: %+v", results[1])
+	}
+}
+
+func TestExecuteBatchRunContextCancel(t *testing.T) {
+	registry := NewRegistry()
+	_ = registry.Register(&slowTool{name: "slow", delay: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	results := NewDispatcher(BatchOptions{MaxParallel: 2}).ExecuteBatch(ctx, newBatch("slow", 4), registry.Get)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("cancelled batch took %s", elapsed)
+	}
+	for i, r := range results {
+		if !errors.Is(r.Err, context.DeadlineExceeded) {
+			t.Fatalf("result %d should carry the run context error, got %v", i, r.Err)
+		}
+	}
+}
+
+func TestSerialKeyClassification(t *testing.T) {
+	ws := filepath.Join(t.TempDir(), "ws")
+	tests := []struct {
+		tool   string
+		params map[string]interface{}
+		want   string
+	}{
+		{"exec", map[string]interface{}{"command": "ls"}, "shell"},
+		{"browser_navigate", nil, "browser"},
+		{"browser_click", nil, "browser"},
+		{"write_file", map[string]interface{}{"path": "a/../b.txt"}, "path:" + filepath.Join(ws, "b.txt")},
+		{"edit_file", map[string]interface{}{"path": filepath.Join(ws, "b.txt")}, "path:" + filepath.Join(ws, "b.txt")},
+		{"write_file", nil, "fs-write"},
+		{"update_config", map[string]interface{}{"file": "config.json"}, "config:config.json"},
+		{"mcp_put_server", nil, "mcp-config"},
+		{"read_file", map[string]interface{}{"path": "b.txt"}, ""},
+		{"web_search", map[string]interface{}{"query": "go"}, ""},
+	}
+	for _, tt := range tests {
+		if got := SerialKey(&mockTool{name: tt.tool}, tt.params, ws); got != tt.want {
+			t.Errorf("SerialKey(%s, %v) = %q, want %q", tt.tool, tt.params, got, tt.want)
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := SerialKey(&mockTool{name: "write_file"}, map[string]interface{}{"path": "b.txt"}, ""), "path:"+filepath.Join(cwd, "b.txt"); got != want {
+		t.Errorf("without a workspace relative paths resolve against the working directory: got %q, want %q", got, want)
+	}
+}
+
+func TestDispatcherScopesShellToTheRun(t *testing.T) {
+	var running, peak int32
+	exec := &funcTool{name: "exec", fn: func(ctx context.Context, params map[string]interface{}) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return "ok", nil
+	}}
+
+	// 每次 exec 都启动独立的 shell，不同运行之间互不阻塞
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = NewDispatcher(BatchOptions{}).Execute(context.Background(), exec, nil)
+		}()
+	}
+	wg.Wait()
+	if peak != 3 {
+		t.Fatalf("exec calls of separate runs should not wait on each other (peak %d)", peak)
+	}
+
+	peak = 0
+	d := NewDispatcher(BatchOptions{})
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = d.Execute(context.Background(), exec, nil)
+		}()
+	}
+	wg.Wait()
+	if peak != 1 {
+		t.Fatalf("exec calls of one run overlapped (peak %d)", peak)
+	}
+}
+
+func TestDispatcherSerialWaitHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	browser := &funcTool{name: "browser_navigate", fn: func(ctx context.Context, params map[string]interface{}) (string, error) {
+		<-release
+		return "ok", nil
+	}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = NewDispatcher(BatchOptions{}).Execute(context.Background(), browser, nil)
+	}()
+	waitForSharedLock(t, "browser")
+
+	// 浏览器会话为进程共享资源：其他运行需要排队，但可随运行上下文取消
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := NewDispatcher(BatchOptions{}).Execute(ctx, browser, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued browser call = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("cancelled call stayed queued for %s", elapsed)
+	}
+
+	close(release)
+	<-done
+	if n := sharedLocks.Len(); n != 0 {
+		t.Fatalf("shared locks kept %d keys", n)
+	}
+}
+
+func waitForSharedLock(t *testing.T, key string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for sharedLocks.Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s lock was never taken", key)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDispatcherLimitsParallelCalls(t *testing.T) {
+	tool := &slowTool{name: "slow", delay: 20 * time.Millisecond}
+	d := NewDispatcher(BatchOptions{MaxParallel: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := d.Execute(context.Background(), tool, map[string]interface{}{"arg": fmt.Sprintf("%d", i)}); err != nil {
+				t.Errorf("Execute: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if peak := atomic.LoadInt32(&tool.peak); peak != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", peak)
+	}
+}
+
+func TestDispatcherSerializesWorkspacePaths(t *testing.T) {
+	ws := t.TempDir()
+	var running, peak int32
+	write := &funcTool{name: "write_file", fn: func(ctx context.Context, params map[string]interface{}) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		if n > atomic.LoadInt32(&peak) {
+			atomic.StoreInt32(&peak, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+		return "ok", nil
+	}}
+
+	// 同一文件的写入即使来自不同运行也必须串行
+	var wg sync.WaitGroup
+	for _, path := range []string{"a.txt", filepath.Join(ws, "a.txt"), "./sub/../a.txt"} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			d := NewDispatcher(BatchOptions{MaxParallel: 4, Workspace: ws})
+			_, _ = d.Execute(context.Background(), write, map[string]interface{}{"path": path})
+		}(path)
+	}
+	wg.Wait()
+
+	if peak != 1 {
+		t.Fatalf("writes to the same workspace file overlapped (peak %d)", peak)
+	}
+	if n := sharedLocks.Len(); n != 0 {
+		t.Fatalf("serial locks kept %d keys after the calls finished", n)
+	}
+}
+
+func TestDispatcherCallTimeout(t *testing.T) {
+	d := NewDispatcher(BatchOptions{CallTimeout: 20 * time.Millisecond})
+	_, err := d.Execute(context.Background(), &slowTool{name: "slow", delay: time.Second}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the call to time out, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := d.Execute(ctx, &slowTool{name: "slow"}, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled run context should stop the call, got %v", err)
+	}
+}
+
+func TestExecuteBatchReadAfterWriteSamePath(t *testing.T) {
+	registry := NewRegistry()
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(context.Context, map[string]interface{}) (string, error) {
+		return func(ctx context.Context, params map[string]interface{}) (string, error) {
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			order = append(order, name+":"+params["path"].(string))
+			mu.Unlock()
+			return "ok", nil
+		}
+	}
+	_ = registry.Register(&funcTool{name: "write_file", fn: record("write")})
+	_ = registry.Register(&funcTool{name: "read_file", fn: record("read")})
+
+	ws := t.TempDir()
+	calls := []ToolCall{
+		{ID: "1", Name: "write_file", Params: map[string]interface{}{"path": "x.txt"}},
+		{ID: "2", Name: "read_file", Params: map[string]interface{}{"path": filepath.Join(ws, "x.txt")}},
+	}
+	NewDispatcher(BatchOptions{MaxParallel: 4, Workspace: ws}).ExecuteBatch(context.Background(), calls, registry.Get)
+
+	if len(order) != 2 || order[0] != "write:x.txt" || order[1] != "read:"+filepath.Join(ws, "x.txt") {
+		t.Fatalf("read of a path written in the same batch must follow the write: %v", order)
+	}
+}
+
+// funcTool 参数无约束、行为由函数决定的工具
+type funcTool struct {
+	name string
+	fn   func(context.Context, map[string]interface{}) (string, error)
+}
+
+func (f *funcTool) Name() string        { return f.name }
+func (f *funcTool) Description() string { return "func tool" }
+func (f *funcTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+func (f *funcTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	return f.fn(ctx, params)
+}
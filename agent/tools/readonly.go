@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ReadOnlyMode 只读模式下对修改类工具的处理方式
+type ReadOnlyMode string
+
+const (
+	// ReadOnlyOff 关闭只读模式
+	ReadOnlyOff ReadOnlyMode = ""
+	// ReadOnlyHide 从工具列表中隐藏修改类工具
+	ReadOnlyHide ReadOnlyMode = "hide"
+	// ReadOnlyError 保留修改类工具，但每次调用都返回 ErrReadOnly
+	ReadOnlyError ReadOnlyMode = "error"
+)
+
+// ErrReadOnly is returned (wrapped) by every mutating tool call in read-only mode.
+var ErrReadOnly = errors.New("read-only mode")
+
+// MutatingClassifier lets a tool declare whether it modifies state, overriding
+// the built-in classification table.
+type MutatingClassifier interface {
+	Mutating() bool
+}
+
+// mutatingTools 会修改文件、配置、记忆或执行命令的内置工具
+var mutatingTools = map[string]bool{
+	"write_file":             true,
+	"edit_file":              true,
+	"update_config":          true,
+	"exec":                   true,
+	"memory_add":             true,
+	"mcp_put_server":         true,
+	"mcp_delete_server":      true,
+	"mcp_set_enabled":        true,
+	"spawn":                  true, // 分身会创建工作目录并使用自己的工具
+	"sessions_spawn":         true,
+	"browser_screenshot":     true, // 截图写入 ~/goclaw-screenshots
+	"browser_print_to_pdf":   true,
+	"browser_execute_script": true,
+	"browser_click":          true, // 可提交表单或触发上传
+	"browser_fill_input":     true,
+}
+
+// readOnlyTools 已确认不修改任何状态的内置工具
+var readOnlyTools = map[string]bool{
+	"read_file":                       true,
+	"list_dir":                        true,
+	"read_config":                     true,
+	"memory_search":                   true,
+	"mcp_list":                        true,
+	"runtime_reload":                  true,
+	"message":                         true,
+	"web_search":                      true,
+	"web_fetch":                       true,
+	"smart_search":                    true,
+	"browser_navigate":                true,
+	"browser_get_text":                true,
+	"browser_extract_structured_data": true,
+	"browser_get_metrics":             true,
+	"browser_emulate_device":          true,
+	"browser_set_viewport":            true,
+	"browser_get_cookies":             true,
+	"browser_close":                   true,
+	"browser_create_tab":              true,
+}
+
+// IsMutating reports whether tool may modify state. Tools that are neither
+// self-classified nor in the built-in tables are treated as mutating, so
+// read-only mode fails closed for tools it does not know.
+func IsMutating(tool Tool) bool {
+	if c, ok := tool.(MutatingClassifier); ok {
+		return c.Mutating()
+	}
+	name := tool.Name()
+	if mutatingTools[name] {
+		return true
+	}
+	return !readOnlyTools[name]
+}
+
+// readOnlyError returns the standard error for calling tool name in read-only mode.
+func readOnlyError(name string) error {
+	return fmt.Errorf("%w: tool %s modifies state and is disabled", ErrReadOnly, name)
+}
+
+// readOnlyGuard 只读模式（error 方式）下替代修改类工具，保留其定义但拒绝执行
+type readOnlyGuard struct {
+	Tool
+}
+
+func (g *readOnlyGuard) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	return "", readOnlyError(g.Name())
+}
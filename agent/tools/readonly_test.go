@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smallnest/goclaw/config"
+)
+
+// builtinTools 构造所有内置工具（不依赖外部服务）
+func builtinTools(t *testing.T) []Tool {
+	t.Helper()
+	ws := t.TempDir()
+	web := NewWebTool("", "", 10)
+
+	var all []Tool
+	all = append(all, NewFileSystemTool(nil, nil, ws).GetTools()...)
+	all = append(all, NewShellTool(true, nil, nil, 10, ws, config.SandboxConfig{}).GetTools()...)
+	all = append(all, web.GetTools()...)
+	all = append(all, NewSmartSearch(web, true, 10).GetTool())
+	all = append(all, NewBrowserTool(true, 10).GetTools()...)
+	all = append(all, NewBrowserCDPTool(true, 10).GetCDPTools()...)
+	all = append(all, NewMessageTool(nil).GetTools()...)
+	all = append(all, NewSpawnTool(nil).GetTools()...)
+	all = append(all,
+		NewSubagentSpawnTool(nil),
+		NewMemoryTool(nil),
+		NewMemoryAddTool(nil),
+		NewRuntimeReloadTool(nil),
+		NewMCPListTool(ws, "skills"),
+		NewMCPPutServerTool(ws, "skills", nil),
+		NewMCPDeleteServerTool(ws, "skills", nil),
+		NewMCPSetEnabledTool(ws, "skills", nil),
+	)
+	return all
+}
+
+func TestBuiltinToolsAreClassified(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, tool := range builtinTools(t) {
+		name := tool.Name()
+		seen[name] = true
+		if mutatingTools[name] == readOnlyTools[name] {
+			t.Errorf("tool %s must be listed in exactly one of mutatingTools/readOnlyTools", name)
+		}
+	}
+	for name := range mutatingTools {
+		if !seen[name] {
+			t.Errorf("mutatingTools lists unknown tool %s", name)
+		}
+	}
+	for name := range readOnlyTools {
+		if !seen[name] {
+			t.Errorf("readOnlyTools lists unknown tool %s", name)
+		}
+	}
+}
+
+func TestIsMutating(t *testing.T) {
+	for _, name := range []string{
+		"write_file", "edit_file", "update_config", "exec", "memory_add",
+		"mcp_put_server", "mcp_delete_server", "mcp_set_enabled",
+		"browser_screenshot", "browser_print_to_pdf",
+	} {
+		if !IsMutating(&mockTool{name: name}) {
+			t.Errorf("%s should be mutating", name)
+		}
+	}
+	for _, name := range []string{"read_file", "list_dir", "read_config", "memory_search", "web_fetch"} {
+		if IsMutating(&mockTool{name: name}) {
+			t.Errorf("%s should not be mutating", name)
+		}
+	}
+	if !IsMutating(&mockTool{name: "custom_tool"}) {
+		t.Error("unknown tools must be treated as mutating")
+	}
+	if IsMutating(&classifiedTool{mockTool{name: "custom_tool"}, false}) {
+		t.Error("MutatingClassifier should override the default")
+	}
+	if !IsMutating(&classifiedTool{mockTool{name: "read_file"}, true}) {
+		t.Error("MutatingClassifier should override the built-in table")
+	}
+}
+
+// classifiedTool 自行声明是否修改状态的工具
+type classifiedTool struct {
+	mockTool
+	mutating bool
+}
+
+func (c *classifiedTool) Mutating() bool { return c.mutating }
+
+func TestReadOnlyHideMode(t *testing.T) {
+	registry := NewRegistry()
+	for _, tool := range builtinTools(t) {
+		if err := registry.Register(tool); err != nil {
+			t.Fatalf("register %s: %v", tool.Name(), err)
+		}
+	}
+	registry.SetReadOnly(ReadOnlyHide)
+
+	for _, tool := range registry.List() {
+		if IsMutating(tool) {
+			t.Errorf("mutating tool %s listed in hide mode", tool.Name())
+		}
+	}
+	if got, want := len(registry.GetDefinitions()), len(registry.List()); got != want {
+		t.Errorf("GetDefinitions returned %d tools, List %d", got, want)
+	}
+	for name := range mutatingTools {
+		if _, ok := registry.Get(name); ok {
+			t.Errorf("Get(%s) should miss in hide mode", name)
+		}
+		if _, err := registry.Execute(context.Background(), name, map[string]interface{}{}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Execute(%s) error = %v, want ErrReadOnly", name, err)
+		}
+	}
+	if _, ok := registry.Get("read_file"); !ok {
+		t.Error("read-only tools must stay available")
+	}
+}
+
+func TestReadOnlyErrorMode(t *testing.T) {
+	registry := NewRegistry()
+	var calls int
+	write := &funcTool{name: "write_file", fn: func(ctx context.Context, params map[string]interface{}) (string, error) {
+		calls++
+		return "written", nil
+	}}
+	read := &funcTool{name: "read_file", fn: func(ctx context.Context, params map[string]interface{}) (string, error) {
+		return "content", nil
+	}}
+	_ = registry.Register(write)
+	_ = registry.Register(read)
+	registry.SetReadOnly(ReadOnlyError)
+
+	if len(registry.List()) != 2 {
+		t.Fatalf("error mode should keep mutating tools listed, got %d tools", len(registry.List()))
+	}
+	if _, err := registry.Execute(context.Background(), "write_file", nil); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Execute(write_file) error = %v, want ErrReadOnly", err)
+	}
+	tool, ok := registry.Get("write_file")
+	if !ok {
+		t.Fatal("error mode should keep write_file available")
+	}
+	if _, err := tool.Execute(context.Background(), nil); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("guarded tool error = %v, want ErrReadOnly", err)
+	}
+	if calls != 0 {
+		t.Fatalf("mutating tool ran %d times in read-only mode", calls)
+	}
+	if out, err := registry.Execute(context.Background(), "read_file", nil); err != nil || out != "content" {
+		t.Fatalf("read_file = %q, %v", out, err)
+	}
+
+	registry.SetReadOnly(ReadOnlyOff)
+	if out, err := registry.Execute(context.Background(), "write_file", nil); err != nil || out != "written" {
+		t.Fatalf("write_file after disabling read-only = %q, %v", out, err)
+	}
+}
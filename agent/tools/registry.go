@@ -11,8 +11,9 @@ import (
 
 // Registry 工具注册表
 type Registry struct {
-	tools map[string]Tool
-	mu    sync.RWMutex
+	tools    map[string]Tool
+	readOnly ReadOnlyMode
+	mu       sync.RWMutex
 }
 
 // NewRegistry 创建工具注册表
@@ -37,6 +38,22 @@ func (r *Registry) Register(tool Tool) error {
 	return nil
 }
 
+// RegisterIfAbsent 注册工具；同名工具已存在时保留已有工具并返回 false。
+// 用于可被多个调用方共享、只需注册一次的工具（如 sessions_spawn）。
+func (r *Registry) RegisterIfAbsent(tool Tool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := tool.Name()
+	if _, ok := r.tools[name]; ok {
+		return false
+	}
+
+	r.tools[name] = tool
+	logger.Info("Tool registered", zap.String("tool", name))
+	return true
+}
+
 // Unregister 注销工具
 func (r *Registry) Unregister(name string) {
 	r.mu.Lock()
@@ -46,13 +63,41 @@ func (r *Registry) Unregister(name string) {
 	logger.Info("Tool unregistered", zap.String("tool", name))
 }
 
+// SetReadOnly 设置只读模式；开启后修改类工具按 mode 被隐藏或拒绝执行
+func (r *Registry) SetReadOnly(mode ReadOnlyMode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readOnly = mode
+}
+
+// ReadOnly 返回当前只读模式
+func (r *Registry) ReadOnly() ReadOnlyMode {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.readOnly
+}
+
+// visible 按只读模式返回调用方可见的工具（调用方需持有读锁）
+func (r *Registry) visible(tool Tool) (Tool, bool) {
+	if r.readOnly == ReadOnlyOff || !IsMutating(tool) {
+		return tool, true
+	}
+	if r.readOnly == ReadOnlyError {
+		return &readOnlyGuard{Tool: tool}, true
+	}
+	return nil, false
+}
+
 // Get 获取工具
 func (r *Registry) Get(name string) (Tool, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	tool, ok := r.tools[name]
-	return tool, ok
+	if !ok {
+		return nil, false
+	}
+	return r.visible(tool)
 }
 
 // List 列出所有工具
@@ -62,18 +107,18 @@ func (r *Registry) List() []Tool {
 
 	tools := make([]Tool, 0, len(r.tools))
 	for _, tool := range r.tools {
-		tools = append(tools, tool)
+		if t, ok := r.visible(tool); ok {
+			tools = append(tools, t)
+		}
 	}
 	return tools
 }
 
 // GetDefinitions 获取所有工具的 OpenAI 格式定义
 func (r *Registry) GetDefinitions() []map[string]interface{} {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	definitions := make([]map[string]interface{}, 0, len(r.tools))
-	for _, tool := range r.tools {
+	tools := r.List()
+	definitions := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
 		definitions = append(definitions, ToSchema(tool))
 	}
 	return definitions
@@ -81,11 +126,20 @@ func (r *Registry) GetDefinitions() []map[string]interface{} {
 
 // Execute 执行工具
 func (r *Registry) Execute(ctx context.Context, name string, params map[string]interface{}) (string, error) {
-	tool, ok := r.Get(name)
+	r.mu.RLock()
+	tool, ok := r.tools[name]
+	readOnly := r.readOnly
+	r.mu.RUnlock()
 	if !ok {
 		return "", fmt.Errorf("tool %s not found", name)
 	}
 
+	// 只读模式：修改类工具无论隐藏与否都返回统一错误
+	if readOnly != ReadOnlyOff && IsMutating(tool) {
+		logger.Warn("Tool call blocked by read-only mode", zap.String("tool", name))
+		return "", readOnlyError(name)
+	}
+
 	// 验证参数
 	if err := ValidateParameters(params, tool.Parameters()); err != nil {
 		return "", fmt.Errorf("parameter validation failed: %w", err)
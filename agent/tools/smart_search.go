@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -11,8 +12,11 @@ import (
 
 	"github.com/mafredri/cdp/protocol/dom"
 	"github.com/mafredri/cdp/protocol/page"
+	"github.com/smallnest/goclaw/internal/htmlmd"
 	"github.com/smallnest/goclaw/internal/logger"
 	"go.uber.org/zap"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
 // SmartSearch Smart search tool supporting web search and browser fallback
@@ -231,25 +235,41 @@ func (s *SmartSearch) fallbackToCrawl4AI(ctx context.Context, query string) (str
 		return fmt.Sprintf("Google Search for: %s\n\nNo results found. The query may not have matching results or Google blocked the request.", query), nil
 	}
 
-	// Format results
-	var formattedResults []string
-	for i, r := range searchResult.Results {
-		formatted := fmt.Sprintf("%d. Title: %s\n   URL: %s", i+1, r.Title, r.Link)
-		if r.Description != "" {
-			formatted += fmt.Sprintf("\n   Description: %s", r.Description)
-		}
-		if r.SiteName != "" {
-			formatted += fmt.Sprintf("\n   Site: %s", r.SiteName)
-		}
-		formattedResults = append(formattedResults, formatted)
+	results := make([]googleResult, 0, len(searchResult.Results))
+	for _, r := range searchResult.Results {
+		results = append(results, googleResult{Title: r.Title, Link: r.Link, Description: r.Description, SiteName: r.SiteName})
 	}
 
 	logger.Info("Successfully extracted search results",
-		zap.Int("result_count", len(formattedResults)))
+		zap.Int("result_count", len(results)))
+
+	return formatGoogleResults(query, results), nil
+}
 
-	return fmt.Sprintf("Google Search Results for: %s\n\n%s",
-		query,
-		strings.Join(formattedResults, "\n\n---\n\n")), nil
+// googleResult is one organic result of a Google search page.
+type googleResult struct {
+	Title       string
+	Link        string
+	Description string
+	SiteName    string
+}
+
+// formatGoogleResults renders results as a numbered Markdown list of title
+// links, each followed by its snippet; this is what smart_search returns.
+func formatGoogleResults(query string, results []googleResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Google Search Results for: %s\n", query)
+	for i, r := range results {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, htmlmd.Link(r.Title, r.Link))
+		if r.SiteName != "" {
+			fmt.Fprintf(&b, " - %s", r.SiteName)
+		}
+		b.WriteString("\n")
+		if r.Description != "" {
+			fmt.Fprintf(&b, "   %s\n", strings.Join(strings.Fields(r.Description), " "))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
 }
 
 // findCrawl4AIScript Find the crawl4ai google_search.py script
@@ -383,91 +403,209 @@ func (s *SmartSearch) fallbackToBrowser(ctx context.Context, query string) (stri
 		return fmt.Sprintf("Google Search for: %s\n\n[⚠️ BLOCKED BY GOOGLE: CAPTCHA/Anti-bot verification required]\n\nGoogle has detected automated traffic from your IP address and requires human verification (CAPTCHA).\n\nPossible solutions:\n1. Wait 10-30 minutes and try again\n2. Try a different network (switch from VPN if using one)\n3. Use alternative search engine\n\nThe search page is showing a verification page instead of results.", query), nil
 	}
 
-	// Extract search results
-	searchResults := s.extractGoogleSearchResults(content)
+	// Extract results from the page DOM and return them as Markdown
+	searchResults, noResults := s.extractGoogleSearchResults(content, googleURL)
+	if noResults {
+		return fmt.Sprintf("Google Search for: %s\n\nNo results found for this search query.", query), nil
+	}
 
-	if searchResults == "" {
+	if len(searchResults) == 0 {
 		// Return partial content for debugging
-		preview := content
-		if len(preview) > 500 {
-			preview = preview[:500] + "..."
+		markdown, err := htmlmd.Convert(content, htmlmd.Options{BaseURL: googleURL, FullPage: true})
+		if err != nil {
+			return fmt.Sprintf("Google Search for: %s\n\n[⚠️ CDP METHOD FAILED]\n\nFailed to convert page content: %v", query, err), nil
 		}
+		preview := htmlmd.Truncate(markdown, 500)
 		return fmt.Sprintf("Google Search for: %s\n\n[⚠️ NO RESULTS EXTRACTED]\n\nNo results could be extracted from the page.\n\nPage preview:\n%s\n\nPossible reasons:\n- Google changed their HTML structure\n- CAPTCHA page was not detected\n- Empty search results\n\nTry:\n1. Waiting and retrying later\n2. Using a different search query", query, preview), nil
 	}
 
-	return fmt.Sprintf("Google Search Results for: %s\n\n%s", query, searchResults), nil
+	return formatGoogleResults(query, searchResults), nil
 }
 
-// extractGoogleSearchResults Extract search results from Google search page HTML
-func (s *SmartSearch) extractGoogleSearchResults(pageText string) string {
-	// Convert HTML to plain text
-	text := htmlToTextForSearch(pageText)
-	lines := strings.Split(text, "\n")
+// extractGoogleSearchResults Extract search results from the HTML of a Google search page.
+// Result links are anchors wrapping an <h3> title (any anchor if the page has none);
+// the snippet is the first link-free text block that follows the anchor.
+// noResults reports a page on which Google says nothing matched.
+func (s *SmartSearch) extractGoogleSearchResults(pageHTML, baseURL string) (results []googleResult, noResults bool) {
+	doc, err := html.Parse(strings.NewReader(pageHTML))
+	if err != nil {
+		return nil, false
+	}
+	base, _ := url.Parse(baseURL)
+
+	// Document order without anchor subtrees, so an anchor ends the previous result's snippet
+	var nodes []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript:
+				return
+			}
+			nodes = append(nodes, n)
+			if n.DataAtom == atom.A {
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
 
-	var results []string
-	var currentResult strings.Builder
-	resultCount := 0
+	titledOnly := false
+	for _, n := range nodes {
+		if n.DataAtom == atom.A && findElement(n, atom.H3) != nil {
+			titledOnly = true
+			break
+		}
+	}
 
-	for i := 0; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
+	seen := make(map[string]bool)
 
-		// Skip empty lines
-		if line == "" {
+	for i, n := range nodes {
+		if n.DataAtom != atom.A || len(results) >= 10 {
 			continue
 		}
+		titleNode := findElement(n, atom.H3)
+		if titleNode == nil {
+			if titledOnly {
+				continue
+			}
+			titleNode = n
+		}
+		title := nodeText(titleNode)
+		link := resolveResultLink(base, nodeAttr(n, "href"))
 
-		// Skip Google UI elements
-		if s.isGoogleUIElement(line) {
+		if link == "" || s.isGoogleUIElement(title) || !s.isResultTitle(title) || seen[link] {
 			continue
 		}
 
-		// Detect possible title
-		if s.isResultTitle(line) {
-			// If existing result, save it
-			if currentResult.Len() > 0 {
-				result := currentResult.String()
-				if s.isValidResult(result) {
-					results = append(results, result)
-					resultCount++
-					if resultCount >= 10 {
-						break
-					}
-				}
-				currentResult.Reset()
-			}
-			currentResult.WriteString(fmt.Sprintf("Title: %s", line))
+		if !s.isValidResult(fmt.Sprintf("Title: %s\nURL: %s", title, link)) {
 			continue
 		}
+		seen[link] = true
+
+		results = append(results, googleResult{
+			Title:       title,
+			Link:        link,
+			Description: resultSnippet(nodes[i+1:], titledOnly),
+		})
+	}
+
+	if len(results) == 0 {
+		// No valid results found
+		noResults = strings.Contains(pageHTML, "No results found") ||
+			strings.Contains(pageHTML, "did not match any documents") ||
+			strings.Contains(pageHTML, "Your search -") && strings.Contains(pageHTML, "- did not match")
+	}
+	return results, noResults
+}
 
-		// If building result, add content
-		if currentResult.Len() > 0 {
-			if s.isURL(line) {
-				currentResult.WriteString(fmt.Sprintf("\nURL: %s", line))
-			} else if len(line) > 20 {
-				currentResult.WriteString(fmt.Sprintf("\nDescription: %s", line))
+// resultSnippet returns the text of the first link-free block before the next result anchor.
+// With titledOnly, anchors without an <h3> (e.g. "Similar" links) do not end the result.
+func resultSnippet(following []*html.Node, titledOnly bool) string {
+	for _, n := range following {
+		switch n.DataAtom {
+		case atom.A:
+			if !titledOnly || findElement(n, atom.H3) != nil {
+				return ""
+			}
+		case atom.Div, atom.Span, atom.P:
+			if findElement(n, atom.A) != nil {
+				continue
 			}
+			if text := nodeText(n); len(text) > 20 {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// resolveResultLink resolves href against the search page and unwraps Google redirects
+func resolveResultLink(base *url.URL, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	return unwrapGoogleRedirect(u.String())
+}
+
+// findElement returns the first descendant of n with the given tag
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			return c
+		}
+		if found := findElement(c, a); found != nil {
+			return found
 		}
 	}
+	return nil
+}
 
-	// Add last result
-	if currentResult.Len() > 0 {
-		result := currentResult.String()
-		if s.isValidResult(result) {
-			results = append(results, result)
+// nodeText returns the whitespace-collapsed text of n
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style) {
+			return
+		}
+		block := n.Type == html.ElementNode && !inlineElements[n.DataAtom]
+		if block {
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			b.WriteByte(' ')
 		}
 	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
 
-	if len(results) == 0 {
-		// No valid results found
-		if strings.Contains(text, "No results found") ||
-			strings.Contains(text, "did not match any documents") ||
-			strings.Contains(text, "Your search -") && strings.Contains(text, "- did not match") {
-			return "No results found for this search query."
+// inlineElements are joined to neighbouring text without a separating space
+var inlineElements = map[atom.Atom]bool{
+	atom.A: true, atom.B: true, atom.Em: true, atom.I: true,
+	atom.Span: true, atom.Strong: true, atom.Code: true, atom.Cite: true,
+}
+
+func nodeAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
 		}
-		return ""
 	}
+	return ""
+}
 
-	return strings.Join(results, "\n\n---\n\n")
+// unwrapGoogleRedirect returns the target of a google.com/url?q=... redirect link
+func unwrapGoogleRedirect(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || !strings.HasSuffix(u.Hostname(), "google.com") || u.Path != "/url" {
+		return link
+	}
+	for _, key := range []string{"q", "url"} {
+		if target := u.Query().Get(key); strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+			return target
+		}
+	}
+	return link
 }
 
 // isGoogleUIElement Check if Google UI element
@@ -523,11 +661,6 @@ func (s *SmartSearch) isResultTitle(line string) bool {
 	return hasContent
 }
 
-// isURL Check if URL
-func (s *SmartSearch) isURL(line string) bool {
-	return strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://")
-}
-
 // isValidResult Check if result is valid
 func (s *SmartSearch) isValidResult(result string) bool {
 	// Must contain title
@@ -611,63 +744,3 @@ func urlEncode(s string) string {
 	}
 	return result.String()
 }
-
-// htmlToTextForSearch Convert HTML to plain text (for search result extraction)
-func htmlToTextForSearch(html string) string {
-	text := ""
-	inTag := false
-	inScript := false
-	inStyle := false
-	tagName := ""
-
-	i := 0
-	for i < len(html) {
-		if html[i] == '<' {
-			inTag = true
-			tagName = ""
-			j := i + 1
-			for j < len(html) && html[j] != '>' && html[j] != ' ' {
-				tagName += string(html[j])
-				j++
-			}
-			if strings.ToLower(tagName) == "script" {
-				inScript = true
-			}
-			if strings.ToLower(tagName) == "style" {
-				inStyle = true
-			}
-			if strings.ToLower(tagName) == "/script" {
-				inScript = false
-			}
-			if strings.ToLower(tagName) == "/style" {
-				inStyle = false
-			}
-			i = j
-			continue
-		}
-
-		if html[i] == '>' {
-			inTag = false
-			i++
-			continue
-		}
-
-		if !inTag && !inScript && !inStyle {
-			text += string(html[i])
-		}
-
-		i++
-	}
-
-	// Clean extra whitespace
-	lines := strings.Split(text, "\n")
-	var cleanLines []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			cleanLines = append(cleanLines, line)
-		}
-	}
-
-	return strings.Join(cleanLines, "\n")
-}
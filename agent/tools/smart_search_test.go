@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractGoogleSearchResults(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "google_serp.html"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	s := NewSmartSearch(NewWebTool("", "", 10), true, 10)
+	results, noResults := s.extractGoogleSearchResults(string(src), "https://www.google.com/search?q=golang")
+	if noResults {
+		t.Fatal("fixture has results")
+	}
+
+	want := strings.Join([]string{
+		"Google Search Results for: golang",
+		"",
+		"1. [The Go Programming Language](https://go.dev/)",
+		"   Go is an open source programming language that makes it simple to build secure, scalable systems.",
+		"",
+		"2. [Go (programming language) - Wikipedia](https://en.wikipedia.org/wiki/Go_(programming_language))",
+		"   Go is a statically typed, compiled high-level programming language designed at Google.",
+		"",
+		`3. [\[v1.2\] release notes](https://github.com/golang/go/releases/tag/go1.22.0)`,
+		"   Release notes for the [v1.2] line, including (breaking) changes and fixes.",
+		"",
+		"4. [Standard library - Go Packages](https://pkg.go.dev/std)",
+	}, "\n")
+	if got := formatGoogleResults("golang", results); got != want {
+		t.Errorf("unexpected results\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestFormatGoogleResultsWithSiteName(t *testing.T) {
+	got := formatGoogleResults("go", []googleResult{{
+		Title:       "Go",
+		Link:        "https://go.dev/a b",
+		Description: "line one\n  line two",
+		SiteName:    "go.dev",
+	}})
+	want := "Google Search Results for: go\n\n1. [Go](https://go.dev/a%20b) - go.dev\n   line one line two"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExtractGoogleSearchResultsNoResults(t *testing.T) {
+	s := NewSmartSearch(NewWebTool("", "", 10), true, 10)
+	page := `<html><body><p>Your search - <b>zzzqqq</b> - did not match any documents.</p><a href="/webhp">Google</a></body></html>`
+	if results, noResults := s.extractGoogleSearchResults(page, "https://www.google.com/search?q=zzzqqq"); !noResults || len(results) != 0 {
+		t.Errorf("got %+v, %v; want a no-results page", results, noResults)
+	}
+	if results, noResults := s.extractGoogleSearchResults("<html><body></body></html>", ""); noResults || len(results) != 0 {
+		t.Errorf("empty page should yield nothing, got %+v, %v", results, noResults)
+	}
+}
+
+func TestUnwrapGoogleRedirect(t *testing.T) {
+	tests := map[string]string{
+		"https://www.google.com/url?q=https://go.dev/&sa=U":      "https://go.dev/",
+		"https://www.google.com/url?url=http://example.com/a(b)": "http://example.com/a(b)",
+		"https://www.google.com/url?q=javascript:alert(1)":       "https://www.google.com/url?q=javascript:alert(1)",
+		"https://example.com/url?q=https://go.dev/":              "https://example.com/url?q=https://go.dev/",
+	}
+	for in, want := range tests {
+		if got := unwrapGoogleRedirect(in); got != want {
+			t.Errorf("unwrapGoogleRedirect(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -179,6 +179,31 @@ type SubagentRegistryInterface interface {
 	RegisterRun(params *SubagentRunParams) error
 }
 
+// SubagentSpawnProvider 提供 sessions_spawn 执行时需要的协作者。
+// 路由本次运行的 AgentManager 实现该接口，并通过 WithSubagentSpawnProvider 放入运行上下文，
+// 这样同一个工具实例可以被共享同一 ToolRegistry 的多个 AgentManager 使用。
+type SubagentSpawnProvider interface {
+	SubagentRegistryInterface
+	AgentConfig(agentID string) *config.AgentConfig
+	AgentDefaults() *config.AgentDefaults
+	AgentIDForSession(sessionKey string) string
+	OnSubagentSpawn(result *SubagentSpawnResult) error
+}
+
+// WithSubagentSpawnProvider 将 provider 绑定到运行上下文
+func WithSubagentSpawnProvider(ctx context.Context, provider SubagentSpawnProvider) context.Context {
+	return context.WithValue(ctx, agentruntime.CtxSubagentSpawnProvider, provider)
+}
+
+// SubagentSpawnProviderFromContext 从运行上下文读取 provider
+func SubagentSpawnProviderFromContext(ctx context.Context) (SubagentSpawnProvider, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	provider, ok := ctx.Value(agentruntime.CtxSubagentSpawnProvider).(SubagentSpawnProvider)
+	return provider, ok && provider != nil
+}
+
 // SubagentSpawnTool 分身生成工具
 type SubagentSpawnTool struct {
 	registry         SubagentRegistryInterface
@@ -188,7 +213,9 @@ type SubagentSpawnTool struct {
 	onSpawn          func(spawnParams *SubagentSpawnResult) error
 }
 
-// NewSubagentSpawnTool 创建分身生成工具
+// NewSubagentSpawnTool 创建分身生成工具。
+// registry 与各 Set* 设置的获取器仅在运行上下文中没有 SubagentSpawnProvider 时使用；
+// 供多个 AgentManager 共享时传 nil 即可。
 func NewSubagentSpawnTool(registry SubagentRegistryInterface) *SubagentSpawnTool {
 	return &SubagentSpawnTool{
 		registry: registry,
@@ -315,6 +342,15 @@ func (t *SubagentSpawnTool) Execute(ctx context.Context, params map[string]inter
 		spawnParams.Cleanup = "keep"
 	}
 
+	provider := t.resolveProvider(ctx)
+	if provider == nil {
+		result := &SubagentSpawnResult{
+			Status: "error",
+			Error:  "sessions_spawn is not bound to an agent manager for this run",
+		}
+		return t.marshalResult(result), nil
+	}
+
 	// 获取请求者会话信息（从上下文获取）
 	requesterSessionKey := readStringContext(ctx, agentruntime.CtxSessionKey)
 	if strings.TrimSpace(requesterSessionKey) == "" {
@@ -322,8 +358,8 @@ func (t *SubagentSpawnTool) Execute(ctx context.Context, params map[string]inter
 	}
 
 	requesterAgentID := readStringContext(ctx, agentruntime.CtxAgentID)
-	if requesterAgentID == "" && t.getAgentID != nil {
-		requesterAgentID = t.getAgentID(requesterSessionKey)
+	if requesterAgentID == "" {
+		requesterAgentID = provider.AgentIDForSession(requesterSessionKey)
 	}
 	if requesterAgentID == "" {
 		requesterAgentID = "default"
@@ -337,7 +373,7 @@ func (t *SubagentSpawnTool) Execute(ctx context.Context, params map[string]inter
 
 	// 验证跨 Agent 创建权限
 	if targetAgentID != requesterAgentID {
-		if !t.checkCrossAgentPermission(requesterAgentID, targetAgentID) {
+		if !checkCrossAgentPermission(provider, requesterAgentID, targetAgentID) {
 			result := &SubagentSpawnResult{
 				Status: "forbidden",
 				Error:  fmt.Sprintf("agentId %s is not allowed for sessions_spawn", targetAgentID),
@@ -371,7 +407,7 @@ func (t *SubagentSpawnTool) Execute(ctx context.Context, params map[string]inter
 	// 获取归档时间
 	archiveAfterMinutes := 60 // 默认值
 	timeoutSeconds := spawnParams.RunTimeoutSeconds
-	if defCfg := t.getDefaultConfig(); defCfg != nil && defCfg.Subagents != nil {
+	if defCfg := provider.AgentDefaults(); defCfg != nil && defCfg.Subagents != nil {
 		if defCfg.Subagents.ArchiveAfterMinutes > 0 {
 			archiveAfterMinutes = defCfg.Subagents.ArchiveAfterMinutes
 		}
@@ -381,7 +417,7 @@ func (t *SubagentSpawnTool) Execute(ctx context.Context, params map[string]inter
 	}
 
 	// 注册分身运行
-	if err := t.registry.RegisterRun(&SubagentRunParams{
+	if err := provider.RegisterRun(&SubagentRunParams{
 		RunID:               runID,
 		ChildSessionKey:     childSessionKey,
 		RequesterSessionKey: requesterSessionKey,
@@ -404,17 +440,15 @@ func (t *SubagentSpawnTool) Execute(ctx context.Context, params map[string]inter
 	}
 
 	// 调用生成回调
-	if t.onSpawn != nil {
-		spawnResult := &SubagentSpawnResult{
-			Status:          "accepted",
-			ChildSessionKey: childSessionKey,
-			RunID:           runID,
-		}
-		if err := t.onSpawn(spawnResult); err != nil {
-			logger.Error("Failed to handle subagent spawn",
-				zap.String("run_id", runID),
-				zap.Error(err))
-		}
+	spawnResult := &SubagentSpawnResult{
+		Status:          "accepted",
+		ChildSessionKey: childSessionKey,
+		RunID:           runID,
+	}
+	if err := provider.OnSubagentSpawn(spawnResult); err != nil {
+		logger.Error("Failed to handle subagent spawn",
+			zap.String("run_id", runID),
+			zap.Error(err))
 	}
 
 	// 构建结果
@@ -543,13 +577,57 @@ func (t *SubagentSpawnTool) marshalResult(result *SubagentSpawnResult) string {
 	}
 }
 
-// checkCrossAgentPermission 检查跨 Agent 创建权限
-func (t *SubagentSpawnTool) checkCrossAgentPermission(requesterID, targetID string) bool {
-	if t.getAgentConfig == nil {
-		return false
+// resolveProvider 优先使用运行上下文中的 provider，否则回退到工具自身配置的注册表和获取器
+func (t *SubagentSpawnTool) resolveProvider(ctx context.Context) SubagentSpawnProvider {
+	if provider, ok := SubagentSpawnProviderFromContext(ctx); ok {
+		return provider
+	}
+	if t.registry == nil {
+		return nil
 	}
+	return &staticSpawnProvider{tool: t}
+}
 
-	agentCfg := t.getAgentConfig(requesterID)
+// staticSpawnProvider 将工具上通过 Set* 配置的获取器适配为 SubagentSpawnProvider
+type staticSpawnProvider struct {
+	tool *SubagentSpawnTool
+}
+
+func (p *staticSpawnProvider) RegisterRun(params *SubagentRunParams) error {
+	return p.tool.registry.RegisterRun(params)
+}
+
+func (p *staticSpawnProvider) AgentConfig(agentID string) *config.AgentConfig {
+	if p.tool.getAgentConfig == nil {
+		return nil
+	}
+	return p.tool.getAgentConfig(agentID)
+}
+
+func (p *staticSpawnProvider) AgentDefaults() *config.AgentDefaults {
+	if p.tool.getDefaultConfig == nil {
+		return nil
+	}
+	return p.tool.getDefaultConfig()
+}
+
+func (p *staticSpawnProvider) AgentIDForSession(sessionKey string) string {
+	if p.tool.getAgentID == nil {
+		return ""
+	}
+	return p.tool.getAgentID(sessionKey)
+}
+
+func (p *staticSpawnProvider) OnSubagentSpawn(result *SubagentSpawnResult) error {
+	if p.tool.onSpawn == nil {
+		return nil
+	}
+	return p.tool.onSpawn(result)
+}
+
+// checkCrossAgentPermission 检查跨 Agent 创建权限
+func checkCrossAgentPermission(provider SubagentSpawnProvider, requesterID, targetID string) bool {
+	agentCfg := provider.AgentConfig(requesterID)
 	if agentCfg == nil || agentCfg.Subagents == nil {
 		return false
 	}
@@ -174,3 +174,73 @@ func TestSubagentSpawnToolExecuteTrimsAgentIDBeforePermissionCheck(t *testing.T)
 		t.Fatalf("registry.RegisterRun should be called for same-agent spawn")
 	}
 }
+
+// mockSpawnProvider 模拟路由运行的 AgentManager
+type mockSpawnProvider struct {
+	mockSubagentRegistry
+	agents   map[string]*config.AgentConfig
+	defaults *config.AgentDefaults
+	spawned  []string
+}
+
+func (p *mockSpawnProvider) AgentConfig(agentID string) *config.AgentConfig {
+	return p.agents[agentID]
+}
+
+func (p *mockSpawnProvider) AgentDefaults() *config.AgentDefaults { return p.defaults }
+
+func (p *mockSpawnProvider) AgentIDForSession(sessionKey string) string { return "" }
+
+func (p *mockSpawnProvider) OnSubagentSpawn(result *SubagentSpawnResult) error {
+	p.spawned = append(p.spawned, result.RunID)
+	return nil
+}
+
+func TestSubagentSpawnToolUsesProviderFromContext(t *testing.T) {
+	// 共享实例不携带任何注册表或获取器
+	tool := NewSubagentSpawnTool(nil)
+
+	first := &mockSpawnProvider{
+		agents: map[string]*config.AgentConfig{
+			"main": {ID: "main", Subagents: &config.AgentSubagentConfig{AllowAgents: []string{"helper"}}},
+		},
+		defaults: &config.AgentDefaults{Subagents: &config.SubagentsConfig{TimeoutSeconds: 11}},
+	}
+	second := &mockSpawnProvider{
+		defaults: &config.AgentDefaults{Subagents: &config.SubagentsConfig{TimeoutSeconds: 22}},
+	}
+	params := map[string]interface{}{"task": "triage logs", "agent_id": "helper"}
+
+	ctx := context.WithValue(context.Background(), agentruntime.CtxAgentID, "main")
+	result, err := tool.Execute(WithSubagentSpawnProvider(ctx, first), params)
+	if err != nil {
+		t.Fatalf("Execute() returned error: %v", err)
+	}
+	if !strings.Contains(result, "Subagent spawned successfully.") {
+		t.Fatalf("unexpected result text: %s", result)
+	}
+	if !first.called || first.params.TimeoutSeconds != 11 || len(first.spawned) != 1 {
+		t.Fatalf("first provider not used: called=%v params=%+v spawned=%v", first.called, first.params, first.spawned)
+	}
+
+	result, err = tool.Execute(WithSubagentSpawnProvider(ctx, second), params)
+	if err != nil {
+		t.Fatalf("Execute() returned error: %v", err)
+	}
+	if !strings.HasPrefix(result, "Forbidden:") {
+		t.Fatalf("second provider does not allow helper, got: %s", result)
+	}
+	if second.called || len(first.spawned) != 1 {
+		t.Fatalf("cross-agent spawn must be checked against the routing provider only")
+	}
+}
+
+func TestSubagentSpawnToolWithoutProviderReturnsError(t *testing.T) {
+	result, err := NewSubagentSpawnTool(nil).Execute(context.Background(), map[string]interface{}{"task": "x"})
+	if err != nil {
+		t.Fatalf("Execute() returned error: %v", err)
+	}
+	if !strings.HasPrefix(result, "Error:") {
+		t.Fatalf("expected unbound tool to report an error, got: %s", result)
+	}
+}
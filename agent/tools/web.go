@@ -10,8 +10,13 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/smallnest/goclaw/internal/htmlmd"
 )
 
+// webFetchRawLimit 非 Markdown 模式下返回内容的最大长度
+const webFetchRawLimit = 10000
+
 // WebTool Web 工具
 type WebTool struct {
 	searchAPIKey string
@@ -215,24 +220,41 @@ func (t *WebTool) WebFetch(ctx context.Context, params map[string]interface{}) (
 		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// 简化实现：返回原始 HTML
-	// 实际应该使用 Readability 转换为 Markdown
-	return t.htmlToMarkdown(string(body)), nil
+	asMarkdown, _ := params["markdown"].(bool)
+	if !asMarkdown || !isHTMLResponse(resp.Header.Get("Content-Type"), body) {
+		return t.rawContent(string(body)), nil
+	}
+
+	maxChars := htmlmd.DefaultMaxChars
+	if m, ok := params["max_chars"].(float64); ok && m > 0 {
+		maxChars = int(m)
+	}
+	md, err := htmlmd.Convert(string(body), htmlmd.Options{
+		BaseURL:  resp.Request.URL.String(),
+		MaxChars: maxChars,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to convert page to markdown: %w", err)
+	}
+	return md, nil
 }
 
-// htmlToMarkdown 简单的 HTML 到 Markdown 转换
-func (t *WebTool) htmlToMarkdown(html string) string {
-	// 移除脚本和样式
+// isHTMLResponse 根据 Content-Type（缺失时按内容嗅探）判断响应是否为 HTML
+func isHTMLResponse(contentType string, body []byte) bool {
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	ct := strings.ToLower(contentType)
+	return strings.Contains(ct, "text/html") || strings.Contains(ct, "application/xhtml")
+}
+
+// rawContent 返回移除脚本和样式后的原始内容
+func (t *WebTool) rawContent(html string) string {
 	html = removeHTMLTags(html, "script")
 	html = removeHTMLTags(html, "style")
 
-	// 简单转换
 	content := strings.TrimSpace(html)
-	if len(content) > 10000 {
-		content = content[:10000] + "\n\n... (truncated)"
-	}
-
-	return content
+	return htmlmd.Truncate(content, webFetchRawLimit)
 }
 
 // removeHTMLTags 移除指定的 HTML 标签
@@ -286,7 +308,7 @@ func (t *WebTool) GetTools() []Tool {
 		),
 		NewBaseTool(
 			"web_fetch",
-			"Fetch a web page and convert to markdown",
+			"Fetch a web page. Set markdown to true to get its main content as compact markdown instead of the raw page",
 			map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -294,6 +316,14 @@ func (t *WebTool) GetTools() []Tool {
 						"type":        "string",
 						"description": "URL to fetch",
 					},
+					"markdown": map[string]interface{}{
+						"type":        "boolean",
+						"description": fmt.Sprintf("Convert HTML pages to markdown (default: false, which returns the raw page with scripts and styles removed, cut at %d chars)", webFetchRawLimit),
+					},
+					"max_chars": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("Maximum characters of markdown to return when markdown is true (default: %d)", htmlmd.DefaultMaxChars),
+					},
 				},
 				"required": []string{"url"},
 			},
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const webFetchPage = `<html><head><title>Fetch Test</title><script>track()</script></head>
+<body><article><h1>Heading</h1><p>First paragraph of the article, long enough to be picked as the main content, with commas, yes.</p>
+<p>See <a href="/docs/Go_(lang)">the docs</a> for more.</p></article></body></html>`
+
+func newWebFetchServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(webFetchPage))
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"html":"<p>not markup</p>"}`))
+	})
+	mux.HandleFunc("/notes.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("# plain text\n[not] a link"))
+	})
+	mux.HandleFunc("/long", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><body><p>" + strings.Repeat("a long sentence, repeated. ", 200) + "</p></body></html>"))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebFetch(t *testing.T) {
+	srv := newWebFetchServer(t)
+	web := NewWebTool("", "", 10)
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		want     string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "html converted to markdown",
+			params:   map[string]interface{}{"url": srv.URL + "/page", "markdown": true},
+			contains: []string{"# Heading", "[the docs](" + srv.URL + "/docs/Go_(lang))"},
+			excludes: []string{"<p>", "track()"},
+		},
+		{
+			name:     "raw html by default",
+			params:   map[string]interface{}{"url": srv.URL + "/page"},
+			contains: []string{"<h1>Heading</h1>", `<a href="/docs/Go_(lang)">`},
+			excludes: []string{"track()"},
+		},
+		{
+			name:   "json returned as is",
+			params: map[string]interface{}{"url": srv.URL + "/data.json", "markdown": true},
+			want:   `{"html":"<p>not markup</p>"}`,
+		},
+		{
+			name:   "plain text returned as is",
+			params: map[string]interface{}{"url": srv.URL + "/notes.txt", "markdown": true},
+			want:   "# plain text\n[not] a link",
+		},
+		{
+			name:     "max_chars truncates",
+			params:   map[string]interface{}{"url": srv.URL + "/long", "markdown": true, "max_chars": float64(50)},
+			contains: []string{"[content truncated at 50 chars]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := web.WebFetch(context.Background(), tt.params)
+			if err != nil {
+				t.Fatalf("WebFetch: %v", err)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("WebFetch = %q, want %q", got, tt.want)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("output missing %q\n%s", s, got)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(got, s) {
+					t.Errorf("output should not contain %q\n%s", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWebFetchErrors(t *testing.T) {
+	srv := newWebFetchServer(t)
+	web := NewWebTool("", "", 10)
+
+	if _, err := web.WebFetch(context.Background(), map[string]interface{}{"url": srv.URL + "/missing"}); err == nil {
+		t.Error("expected error for 404 response")
+	}
+	if _, err := web.WebFetch(context.Background(), map[string]interface{}{"url": "file:///etc/passwd"}); err == nil {
+		t.Error("expected error for non-http URL")
+	}
+}
@@ -40,6 +40,7 @@ var (
 	agentDeliver   bool
 	agentJSON      bool
 	agentTimeout   int
+	agentReadOnly  bool
 )
 
 func init() {
@@ -53,6 +54,7 @@ func init() {
 	agentCmd.Flags().BoolVar(&agentDeliver, "deliver", false, "Deliver response through the channel")
 	agentCmd.Flags().BoolVar(&agentJSON, "json", false, "Output in JSON format")
 	agentCmd.Flags().IntVar(&agentTimeout, "timeout", 120, "Timeout in seconds")
+	agentCmd.Flags().BoolVar(&agentReadOnly, "read-only", false, "Read-only mode: disable tools and writers that modify anything")
 
 	_ = agentCmd.MarkFlagRequired("message")
 }
@@ -80,6 +82,9 @@ func runAgent(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
+	if agentReadOnly {
+		cfg.ReadOnly.Enabled = true
+	}
 
 	// Create workspace
 	workspace, err := config.GetWorkspacePath(cfg)
@@ -120,9 +125,11 @@ func runAgent(cmd *cobra.Command, args []string) {
 		contextCfg.Limit = 6
 	}
 	memoryStore := agent.NewMemoryStore(workspace, searchMgr, contextCfg.Query, contextCfg.Limit, contextCfg.Enabled)
-	if err := memoryStore.EnsureBootstrapFiles(); err != nil {
-		if agentVerbose {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to create bootstrap files: %v\n", err)
+	if !cfg.ReadOnly.Enabled {
+		if err := memoryStore.EnsureBootstrapFiles(); err != nil {
+			if agentVerbose {
+				fmt.Fprintf(os.Stderr, "Warning: Failed to create bootstrap files: %v\n", err)
+			}
 		}
 	}
 
@@ -130,6 +137,7 @@ func runAgent(cmd *cobra.Command, args []string) {
 	toolRegistry := agent.NewToolRegistry()
 	contextBuilder := agent.NewContextBuilder(memoryStore, workspace)
 	contextBuilder.SetToolRegistry(toolRegistry)
+	agent.ApplyReadOnly(cfg, toolRegistry, contextBuilder)
 
 	// Runtime invalidator (tools call this; mainRuntime is assigned later).
 	var mainRuntime *agent.AgentSDKMainRuntime
@@ -326,23 +334,28 @@ func runAgent(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	channel, accountID, chatID := parseSessionKey(sessionKey)
+	// Pins follow the chat, not the fresh session generated for it.
+	pinChatKey := agent.PinChatKeyFor(agentSessionID, agent.SessionRef{Channel: agentChannel, AccountID: "agent"})
+
+	ref := agent.ParseSessionRef(sessionKey).WithDefaults("cli")
 	runCtx := context.WithValue(ctx, agentruntime.CtxSessionKey, sessionKey)
 	runCtx = context.WithValue(runCtx, agentruntime.CtxAgentID, runAgentID)
-	runCtx = context.WithValue(runCtx, agentruntime.CtxChannel, channel)
-	runCtx = context.WithValue(runCtx, agentruntime.CtxAccountID, accountID)
-	runCtx = context.WithValue(runCtx, agentruntime.CtxChatID, chatID)
+	runCtx = context.WithValue(runCtx, agentruntime.CtxChannel, ref.Channel)
+	runCtx = context.WithValue(runCtx, agentruntime.CtxAccountID, ref.AccountID)
+	runCtx = context.WithValue(runCtx, agentruntime.CtxChatID, ref.ChatID)
+	runCtx = agentManager.RunContext(runCtx)
 
 	runResp, err := mainRuntime.Run(runCtx, agent.MainRunRequest{
-		AgentID:      runAgentID,
-		SessionKey:   sessionKey,
-		Prompt:       agentMessage,
-		SystemPrompt: runSystemPrompt,
-		Workspace:    runWorkspace,
+		AgentID:       runAgentID,
+		SessionKey:    sessionKey,
+		Prompt:        agentMessage,
+		SystemPrompt:  runSystemPrompt,
+		Workspace:     runWorkspace,
+		PinnedContext: contextBuilder.BuildPinnedContext(pinChatKey),
 		Metadata: map[string]any{
-			"channel":    channel,
-			"account_id": accountID,
-			"chat_id":    chatID,
+			"channel":    ref.Channel,
+			"account_id": ref.AccountID,
+			"chat_id":    ref.ChatID,
 		},
 	})
 	if err != nil {
@@ -410,24 +423,14 @@ func deliverResponse(ctx context.Context, messageBus *bus.MessageBus, content st
 	})
 }
 
-func parseSessionKey(sessionKey string) (channel string, accountID string, chatID string) {
-	parts := strings.Split(strings.TrimSpace(sessionKey), ":")
-	switch {
-	case len(parts) >= 3:
-		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(strings.Join(parts[2:], ":"))
-	case len(parts) == 2:
-		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), "default"
-	case len(parts) == 1 && strings.TrimSpace(parts[0]) != "":
-		return "cli", "default", strings.TrimSpace(parts[0])
-	default:
-		return "cli", "default", "default"
-	}
-}
-
 func exportSessionMarkdown(cfg *config.Config, sessionMgr *session.Manager, sess *session.Session, verbose bool) {
 	if cfg == nil || sessionMgr == nil || sess == nil {
 		return
 	}
+	// 只读模式下不把会话导出到记忆目录
+	if cfg.ReadOnly.Enabled {
+		return
+	}
 
 	memCfg := cfg.Memory
 	if strings.TrimSpace(memCfg.Backend) != "" && strings.TrimSpace(memCfg.Backend) != "memsearch" {
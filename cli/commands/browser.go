@@ -17,8 +17,10 @@ import (
 	"github.com/mafredri/cdp/protocol/page"
 	"github.com/mafredri/cdp/protocol/runtime"
 	"github.com/mafredri/cdp/protocol/target"
+	"github.com/smallnest/goclaw/agent"
 	"github.com/smallnest/goclaw/agent/tools"
 	"github.com/smallnest/goclaw/config"
+	"github.com/smallnest/goclaw/internal/htmlmd"
 	"github.com/spf13/cobra"
 )
 
@@ -26,19 +28,41 @@ import (
 type BrowserCommandRegistry struct {
 	sessionMgr *tools.BrowserSessionManager
 	homeDir    string
+	readOnly   tools.ReadOnlyMode // 只读模式下禁止写文件的命令（截图、快照、PDF、重置配置）
+	host       *CommandRegistry   // 注册到的命令注册表，其只读设置同样生效
 }
 
 // NewBrowserCommandRegistry Create browser command registry
 func NewBrowserCommandRegistry() *BrowserCommandRegistry {
 	homeDir, _ := config.ResolveUserHomeDir()
+	readOnly := tools.ReadOnlyOff
+	if cfg, err := config.Load(""); err == nil {
+		readOnly = agent.ReadOnlyToolMode(cfg)
+	}
 	return &BrowserCommandRegistry{
 		sessionMgr: tools.GetBrowserSession(),
 		homeDir:    homeDir,
+		readOnly:   readOnly,
+	}
+}
+
+// SetReadOnly 设置只读模式
+func (r *BrowserCommandRegistry) SetReadOnly(mode tools.ReadOnlyMode) {
+	r.readOnly = mode
+}
+
+// isReadOnly 报告是否处于只读模式（自身或所属命令注册表）
+func (r *BrowserCommandRegistry) isReadOnly() bool {
+	if r.host != nil && r.host.readOnly != tools.ReadOnlyOff {
+		return true
 	}
+	return r.readOnly != tools.ReadOnlyOff
 }
 
 // RegisterCommands Register browser commands with the command registry
 func (r *BrowserCommandRegistry) RegisterCommands(registry *CommandRegistry) {
+	r.host = registry
+
 	// browser status - Show status
 	registry.Register(&Command{
 		Name:        "browser",
@@ -125,8 +149,8 @@ func (r *BrowserCommandRegistry) RegisterCommands(registry *CommandRegistry) {
 	// browser snapshot - Take snapshot
 	registry.Register(&Command{
 		Name:        "browser-snapshot",
-		Usage:       "/browser snapshot",
-		Description: "Take page snapshot (HTML + screenshot)",
+		Usage:       "/browser snapshot [--markdown]",
+		Description: "Take page snapshot (HTML + screenshot, optionally Markdown)",
 		Handler:     r.browserSnapshot,
 	})
 
@@ -284,6 +308,10 @@ func (r *BrowserCommandRegistry) browserStop(args []string) (string, bool) {
 
 // browserResetProfile Reset browser profile
 func (r *BrowserCommandRegistry) browserResetProfile(args []string) (string, bool) {
+	if r.isReadOnly() {
+		return "Read-only mode: the browser profile cannot be reset.", false
+	}
+
 	// Stop browser first
 	if r.sessionMgr.IsReady() {
 		r.sessionMgr.Stop()
@@ -452,6 +480,10 @@ func (r *BrowserCommandRegistry) browserProfiles(args []string) (string, bool) {
 
 // browserScreenshot Take screenshot
 func (r *BrowserCommandRegistry) browserScreenshot(args []string) (string, bool) {
+	if r.isReadOnly() {
+		return "Read-only mode: screenshots cannot be saved.", false
+	}
+
 	if !r.sessionMgr.IsReady() {
 		return "Browser is not running", false
 	}
@@ -486,6 +518,10 @@ func (r *BrowserCommandRegistry) browserScreenshot(args []string) (string, bool)
 
 // browserSnapshot Take page snapshot
 func (r *BrowserCommandRegistry) browserSnapshot(args []string) (string, bool) {
+	if r.isReadOnly() {
+		return "Read-only mode: snapshots cannot be saved.", false
+	}
+
 	if !r.sessionMgr.IsReady() {
 		return "Browser is not running", false
 	}
@@ -528,7 +564,33 @@ func (r *BrowserCommandRegistry) browserSnapshot(args []string) (string, bool) {
 	_ = os.WriteFile(htmlPath, []byte(html.OuterHTML), 0644)
 	_ = os.WriteFile(imgPath, screenshot.Data, 0644)
 
-	return fmt.Sprintf("Snapshot saved:\n  HTML: %s\n  Image: %s", htmlPath, imgPath), false
+	result := fmt.Sprintf("Snapshot saved:\n  HTML: %s\n  Image: %s", htmlPath, imgPath)
+	if !hasFlag(args, "--markdown") {
+		return result, false
+	}
+
+	pageURL := ""
+	if doc.Root.DocumentURL != nil {
+		pageURL = *doc.Root.DocumentURL
+	}
+	md, err := htmlmd.Convert(html.OuterHTML, htmlmd.Options{BaseURL: pageURL})
+	if err != nil {
+		return fmt.Sprintf("%s\nFailed to convert to Markdown: %v", result, err), false
+	}
+	mdPath := filepath.Join(snapshotDir, fmt.Sprintf("snapshot_%d.md", timestamp))
+	_ = os.WriteFile(mdPath, []byte(md), 0644)
+
+	return fmt.Sprintf("%s\n  Markdown: %s\n\n%s", result, mdPath, htmlmd.Truncate(md, htmlmd.DefaultMaxChars)), false
+}
+
+// hasFlag reports whether flag appears in args
+func hasFlag(args []string, flag string) bool {
+	for _, arg := range args {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
 }
 
 // browserNavigate Navigate to URL
@@ -1151,6 +1213,10 @@ func (r *BrowserCommandRegistry) browserConsole(args []string) (string, bool) {
 
 // browserPDF Save page as PDF
 func (r *BrowserCommandRegistry) browserPDF(args []string) (string, bool) {
+	if r.isReadOnly() {
+		return "Read-only mode: PDFs cannot be saved.", false
+	}
+
 	filename := fmt.Sprintf("page_%d.pdf", time.Now().Unix())
 	if len(args) > 0 {
 		filename = args[0]
@@ -1296,6 +1362,8 @@ var browserScreenshotCmd = &cobra.Command{
 	Run:   runBrowserScreenshot,
 }
 
+var browserSnapshotMarkdown bool
+
 var browserSnapshotCmd = &cobra.Command{
 	Use:   "snapshot",
 	Short: "Take page snapshot",
@@ -1426,6 +1494,8 @@ func init() {
 	browserCmd.AddCommand(browserEvaluateCmd)
 	browserCmd.AddCommand(browserConsoleCmd)
 	browserCmd.AddCommand(browserPdfCmd)
+
+	browserSnapshotCmd.Flags().BoolVar(&browserSnapshotMarkdown, "markdown", false, "Also convert the page to Markdown and print it")
 }
 
 // BrowserCommand returns the browser cobra command
@@ -1496,6 +1566,9 @@ func runBrowserScreenshot(cmd *cobra.Command, args []string) {
 
 func runBrowserSnapshot(cmd *cobra.Command, args []string) {
 	registry := NewBrowserCommandRegistry()
+	if browserSnapshotMarkdown {
+		args = append(args, "--markdown")
+	}
 	result, _ := registry.browserSnapshot(args)
 	fmt.Println(result)
 }
@@ -13,6 +13,8 @@ import (
 
 	"github.com/chzyer/readline"
 	"github.com/manifoldco/promptui"
+	"github.com/smallnest/goclaw/agent"
+	"github.com/smallnest/goclaw/agent/tools"
 	"github.com/smallnest/goclaw/config"
 	"github.com/smallnest/goclaw/session"
 )
@@ -46,6 +48,10 @@ type CommandRegistry struct {
 	stopped      bool                                   // 停止标志，用于中止正在运行的 agent
 	toolGetter   func() (map[string]interface{}, error) // 获取工具列表的函数
 	skillsGetter func() ([]*SkillInfo, error)           // 获取技能列表的函数
+
+	contextBuilder *agent.ContextBuilder // 置顶笔记来源（见 SetPins）
+	pinChatKey     string
+	readOnly       tools.ReadOnlyMode // 只读模式（见 SetReadOnly）
 }
 
 // SkillInfo 技能信息
@@ -80,6 +86,19 @@ func NewCommandRegistry() *CommandRegistry {
 	return registry
 }
 
+// SetReadOnly 设置只读模式，/status 会显示该状态，删除类命令被禁用
+func (r *CommandRegistry) SetReadOnly(mode tools.ReadOnlyMode) {
+	r.readOnly = mode
+}
+
+// readOnlyToolsLabel 描述只读模式下修改类工具的处理方式
+func readOnlyToolsLabel(mode tools.ReadOnlyMode) string {
+	if mode == tools.ReadOnlyError {
+		return "return a read-only error"
+	}
+	return "are hidden"
+}
+
 // SetSessionManager 设置会话管理器
 func (r *CommandRegistry) SetSessionManager(mgr *session.Manager) {
 	r.sessionMgr = mgr
@@ -153,6 +172,9 @@ func (r *CommandRegistry) registerBuiltInCommands() {
 		Usage:       "/clear-sessions",
 		Description: "Clear all saved session files (restart recommended)",
 		Handler: func(args []string) (string, bool) {
+			if r.readOnly != tools.ReadOnlyOff {
+				return "Read-only mode: sessions cannot be cleared.", false
+			}
 			sessionDir := filepath.Join(r.homeDir, ".goclaw", "sessions")
 			// 检查目录是否存在
 			if _, err := os.Stat(sessionDir); os.IsNotExist(err) {
@@ -388,6 +410,10 @@ func (r *CommandRegistry) handleStatus(args []string) string {
 	var sb strings.Builder
 	sb.WriteString("=== goclaw Status ===\n\n")
 
+	if r.readOnly != tools.ReadOnlyOff {
+		sb.WriteString(fmt.Sprintf("🔒 READ-ONLY MODE (mutating tools %s)\n\n", readOnlyToolsLabel(r.readOnly)))
+	}
+
 	// Gateway status
 	gatewayStatus := r.checkGatewayStatus(5)
 	sb.WriteString("Gateway:\n")
@@ -401,6 +427,9 @@ func (r *CommandRegistry) handleStatus(args []string) string {
 			t := time.Unix(gatewayStatus.Timestamp, 0)
 			sb.WriteString(fmt.Sprintf("  Uptime:  %s\n", t.Format(time.RFC3339)))
 		}
+		if gatewayStatus.ReadOnly {
+			sb.WriteString("  Mode:    READ-ONLY\n")
+		}
 	} else {
 		sb.WriteString("  Status:  Offline\n")
 		sb.WriteString("  Tip:     Start gateway with 'goclaw gateway run'\n")
@@ -510,6 +539,9 @@ func (r *CommandRegistry) checkGatewayStatus(timeout int) GatewayStatus {
 				if ts, ok := health["time"].(float64); ok {
 					result.Timestamp = int64(ts)
 				}
+				if readOnly, ok := health["read_only"].(bool); ok {
+					result.ReadOnly = readOnly
+				}
 
 				break
 			}
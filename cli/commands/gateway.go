@@ -38,6 +38,7 @@ var (
 	gatewayForce     bool
 	gatewayVerbose   bool
 	gatewayParams    string
+	gatewayReadOnly  bool
 )
 
 // GatewayCommand returns the gateway command
@@ -64,6 +65,7 @@ func GatewayCommand() *cobra.Command {
 	runCmd.Flags().BoolVar(&gatewayReset, "reset", false, "Reset configuration")
 	runCmd.Flags().BoolVarP(&gatewayForce, "force", "f", false, "Force start")
 	runCmd.Flags().BoolVarP(&gatewayVerbose, "verbose", "v", false, "Verbose output")
+	runCmd.Flags().BoolVar(&gatewayReadOnly, "read-only", false, "Read-only mode: reject configuration changes and report read_only in health")
 
 	// Gateway status command
 	statusCmd := &cobra.Command{
@@ -167,6 +169,9 @@ func runGateway(cmd *cobra.Command, args []string) {
 	if gatewayBind != "" {
 		cfg.Gateway.Host = gatewayBind
 	}
+	if gatewayReadOnly {
+		cfg.ReadOnly.Enabled = true
+	}
 
 	// Create components
 	messageBus := bus.NewMessageBus(100)
@@ -209,6 +214,7 @@ func runGateway(cmd *cobra.Command, args []string) {
 	}
 
 	gatewayServer.SetWebSocketConfig(wsConfig)
+	gatewayServer.SetReadOnly(cfg.ReadOnly.Enabled)
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -235,6 +241,9 @@ func runGateway(cmd *cobra.Command, args []string) {
 	if gatewayAuth || gatewayToken != "" || gatewayPassword != "" {
 		fmt.Println("Authentication: enabled")
 	}
+	if cfg.ReadOnly.Enabled {
+		fmt.Println("🔒 Read-only mode: enabled")
+	}
 
 	fmt.Println("\nPress Ctrl+C to stop")
 
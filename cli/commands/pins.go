@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/smallnest/goclaw/agent"
+)
+
+// SetPins enables /pin, /pins and /unpin for chatKey and lets
+// PinnedContext render the chat's pinned facts for run requests.
+func (r *CommandRegistry) SetPins(contextBuilder *agent.ContextBuilder, chatKey string) {
+	r.contextBuilder = contextBuilder
+	r.pinChatKey = strings.TrimSpace(chatKey)
+
+	for _, spec := range []struct{ name, usage, desc string }{
+		{"pin", "/pin <text>", "Pin a note to this chat (kept across sessions)"},
+		{"pins", "/pins list", "List pinned notes for this chat"},
+		{"unpin", "/unpin <n>", "Remove the n-th pinned note"},
+	} {
+		name := spec.name
+		r.Register(&Command{
+			Name:        name,
+			Usage:       spec.usage,
+			Description: spec.desc,
+			Handler: func(args []string) (string, bool) {
+				cmd, ok := agent.ParsePinCommand("/" + name + " " + strings.Join(args, " "))
+				if !ok {
+					return "Usage: " + spec.usage, false
+				}
+				return agent.ExecutePinCommand(r.contextBuilder.Pins(), r.pinChatKey, cmd), false
+			},
+		})
+	}
+}
+
+// PinnedContext renders the pinned facts of the current chat, or "" if there are none.
+func (r *CommandRegistry) PinnedContext() string {
+	if r == nil {
+		return ""
+	}
+	return r.contextBuilder.BuildPinnedContext(r.pinChatKey)
+}
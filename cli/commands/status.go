@@ -62,6 +62,7 @@ type GatewayStatus struct {
 	Status    string `json:"status,omitempty"`
 	Version   string `json:"version,omitempty"`
 	Timestamp int64  `json:"timestamp,omitempty"`
+	ReadOnly  bool   `json:"read_only,omitempty"`
 }
 
 // SystemStatus represents overall system status
@@ -133,6 +134,9 @@ func checkGatewayStatus(timeout int) GatewayStatus {
 				if ts, ok := health["time"].(float64); ok {
 					result.Timestamp = int64(ts)
 				}
+				if readOnly, ok := health["read_only"].(bool); ok {
+					result.ReadOnly = readOnly
+				}
 
 				break
 			}
@@ -228,6 +232,9 @@ func outputStatusText(status *SystemStatus) {
 			t := time.Unix(status.Gateway.Timestamp, 0)
 			fmt.Printf("  Uptime:  %s\n", t.Format(time.RFC3339))
 		}
+		if status.Gateway.ReadOnly {
+			fmt.Printf("  Mode:    🔒 READ-ONLY\n")
+		}
 	} else {
 		fmt.Printf("  Status:  Offline\n")
 		fmt.Printf("  Tip:     Start gateway with 'goclaw gateway run'\n")
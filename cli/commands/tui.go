@@ -34,6 +34,7 @@ var (
 	tuiMessage      string
 	tuiTimeoutMs    int
 	tuiHistoryLimit int
+	tuiReadOnly     bool
 )
 
 // TUICommand returns the tui command
@@ -54,6 +55,7 @@ func TUICommand() *cobra.Command {
 	cmd.Flags().StringVar(&tuiMessage, "message", "", "Send message on start")
 	cmd.Flags().IntVar(&tuiTimeoutMs, "timeout-ms", 600000, "Timeout in milliseconds")
 	cmd.Flags().IntVar(&tuiHistoryLimit, "history-limit", 50, "History limit")
+	cmd.Flags().BoolVar(&tuiReadOnly, "read-only", false, "Read-only mode: disable tools and writers that modify anything")
 
 	return cmd
 }
@@ -66,6 +68,9 @@ func runTUI(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
+	if tuiReadOnly {
+		cfg.ReadOnly.Enabled = true
+	}
 
 	// Initialize logger
 	logLevel := "info"
@@ -116,12 +121,15 @@ func runTUI(cmd *cobra.Command, args []string) {
 		contextCfg.Limit = 6
 	}
 	memoryStore := agent.NewMemoryStore(workspace, searchMgr, contextCfg.Query, contextCfg.Limit, contextCfg.Enabled)
-	_ = memoryStore.EnsureBootstrapFiles()
+	if !cfg.ReadOnly.Enabled {
+		_ = memoryStore.EnsureBootstrapFiles()
+	}
 
 	// Create tool registry
 	toolRegistry := agent.NewToolRegistry()
 	contextBuilder := agent.NewContextBuilder(memoryStore, workspace)
 	contextBuilder.SetToolRegistry(toolRegistry)
+	agent.ApplyReadOnly(cfg, toolRegistry, contextBuilder)
 
 	// Runtime invalidator (tools call this; mainRuntime is assigned later).
 	var mainRuntime *agent.AgentSDKMainRuntime
@@ -266,6 +274,9 @@ func runTUI(cmd *cobra.Command, args []string) {
 	fmt.Printf("New Session: %s\n", sessionKey)
 	fmt.Printf("History limit: %d\n", tuiHistoryLimit)
 	fmt.Printf("Timeout: %d ms\n", tuiTimeoutMs)
+	if cfg.ReadOnly.Enabled {
+		fmt.Printf("🔒 Read-only mode: mutating tools %s\n", readOnlyToolsLabel(agent.ReadOnlyToolMode(cfg)))
+	}
 	fmt.Println()
 
 	// Create context
@@ -275,6 +286,7 @@ func runTUI(cmd *cobra.Command, args []string) {
 	// Create command registry for slash commands
 	cmdRegistry := NewCommandRegistry()
 	cmdRegistry.SetSessionManager(sessionMgr)
+	cmdRegistry.SetReadOnly(agent.ReadOnlyToolMode(cfg))
 	cmdRegistry.SetToolGetter(func() (map[string]interface{}, error) {
 		// 从 toolRegistry 获取工具信息
 		existingTools := toolRegistry.ListExisting()
@@ -293,6 +305,9 @@ func runTUI(cmd *cobra.Command, args []string) {
 		return []*SkillInfo{}, nil
 	})
 
+	// Pins belong to the chat, so a fresh TUI session still sees them.
+	cmdRegistry.SetPins(contextBuilder, agent.PinChatKeyFor(tuiSession, agent.SessionRef{Channel: "tui", AccountID: "tui"}))
+
 	// Handle message flag
 	if tuiMessage != "" {
 		fmt.Printf("Sending message: %s\n", tuiMessage)
@@ -474,24 +489,26 @@ func runAgentIteration(
 		}
 	}
 
-	channel, accountID, chatID := parseSessionKey(sess.Key)
+	ref := agent.ParseSessionRef(sess.Key).WithDefaults("cli")
 	runCtx := context.WithValue(ctx, agentruntime.CtxSessionKey, sess.Key)
 	runCtx = context.WithValue(runCtx, agentruntime.CtxAgentID, runAgentID)
-	runCtx = context.WithValue(runCtx, agentruntime.CtxChannel, channel)
-	runCtx = context.WithValue(runCtx, agentruntime.CtxAccountID, accountID)
-	runCtx = context.WithValue(runCtx, agentruntime.CtxChatID, chatID)
+	runCtx = context.WithValue(runCtx, agentruntime.CtxChannel, ref.Channel)
+	runCtx = context.WithValue(runCtx, agentruntime.CtxAccountID, ref.AccountID)
+	runCtx = context.WithValue(runCtx, agentruntime.CtxChatID, ref.ChatID)
+	runCtx = agentManager.RunContext(runCtx)
 
 	if streamer, ok := mainRuntime.(agent.MainRuntimeStreamer); ok {
 		stream, err := streamer.RunStream(runCtx, agent.MainRunRequest{
-			AgentID:      runAgentID,
-			SessionKey:   sess.Key,
-			Prompt:       prompt,
-			SystemPrompt: runSystemPrompt,
-			Workspace:    runWorkspace,
+			AgentID:       runAgentID,
+			SessionKey:    sess.Key,
+			Prompt:        prompt,
+			SystemPrompt:  runSystemPrompt,
+			Workspace:     runWorkspace,
+			PinnedContext: cmdRegistry.PinnedContext(),
 			Metadata: map[string]any{
-				"channel":    channel,
-				"account_id": accountID,
-				"chat_id":    chatID,
+				"channel":    ref.Channel,
+				"account_id": ref.AccountID,
+				"chat_id":    ref.ChatID,
 			},
 		})
 		if err != nil {
@@ -514,15 +531,16 @@ func runAgentIteration(
 	}
 
 	resp, err := mainRuntime.Run(runCtx, agent.MainRunRequest{
-		AgentID:      runAgentID,
-		SessionKey:   sess.Key,
-		Prompt:       prompt,
-		SystemPrompt: runSystemPrompt,
-		Workspace:    runWorkspace,
+		AgentID:       runAgentID,
+		SessionKey:    sess.Key,
+		Prompt:        prompt,
+		SystemPrompt:  runSystemPrompt,
+		Workspace:     runWorkspace,
+		PinnedContext: cmdRegistry.PinnedContext(),
 		Metadata: map[string]any{
-			"channel":    channel,
-			"account_id": accountID,
-			"chat_id":    chatID,
+			"channel":    ref.Channel,
+			"account_id": ref.AccountID,
+			"chat_id":    ref.ChatID,
 		},
 	})
 	if err != nil {
@@ -613,24 +631,14 @@ func findMostRecentTUISession(mgr *session.Manager) string {
 	return tuiSessions[0].key
 }
 
-func parseSessionKey(sessionKey string) (channel string, accountID string, chatID string) {
-	parts := strings.Split(strings.TrimSpace(sessionKey), ":")
-	switch {
-	case len(parts) >= 3:
-		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(strings.Join(parts[2:], ":"))
-	case len(parts) == 2:
-		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), "default"
-	case len(parts) == 1 && strings.TrimSpace(parts[0]) != "":
-		return "cli", "default", strings.TrimSpace(parts[0])
-	default:
-		return "cli", "default", "default"
-	}
-}
-
 func exportSessionMarkdown(cfg *config.Config, sessionMgr *session.Manager, sess *session.Session) {
 	if cfg == nil || sessionMgr == nil || sess == nil {
 		return
 	}
+	// 只读模式下不把会话导出到记忆目录
+	if cfg.ReadOnly.Enabled {
+		return
+	}
 
 	memCfg := cfg.Memory
 	if strings.TrimSpace(memCfg.Backend) != "" && strings.TrimSpace(memCfg.Backend) != "memsearch" {
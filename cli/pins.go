@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/smallnest/goclaw/agent"
+	"github.com/smallnest/goclaw/agent/tools"
+	"github.com/smallnest/goclaw/config"
+	"github.com/spf13/cobra"
+)
+
+var pinsCmd = &cobra.Command{
+	Use:   "pins",
+	Short: "Manage pinned notes of a chat",
+	Long: `Pinned notes are per-chat facts (keyed by channel:account:chat) that are
+injected into every conversation of that chat, across session rotation and restarts.`,
+}
+
+var pinsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pinned notes",
+	Args:  cobra.NoArgs,
+	Run:   runPinsList,
+}
+
+var pinsAddCmd = &cobra.Command{
+	Use:   "add <text>",
+	Short: "Pin a note",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runPinsAdd,
+}
+
+var pinsRmCmd = &cobra.Command{
+	Use:   "rm <n>",
+	Short: "Remove the n-th pinned note",
+	Args:  cobra.ExactArgs(1),
+	Run:   runPinsRm,
+}
+
+var pinsChatKey string
+
+func init() {
+	pinsCmd.PersistentFlags().StringVar(&pinsChatKey, "chat", "", "Chat key (channel:account:chat), e.g. qq:acct:g123")
+	_ = pinsCmd.MarkPersistentFlagRequired("chat")
+
+	rootCmd.AddCommand(pinsCmd)
+	pinsCmd.AddCommand(pinsListCmd)
+	pinsCmd.AddCommand(pinsAddCmd)
+	pinsCmd.AddCommand(pinsRmCmd)
+}
+
+// loadPinStore opens the pin store of the configured workspace; in read-only
+// mode add and rm fail like /pin does in a chat
+func loadPinStore() *agent.PinStore {
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	workspace, err := config.GetWorkspacePath(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get workspace path: %v\n", err)
+		os.Exit(1)
+	}
+	store := agent.NewPinStore(workspace)
+	store.SetReadOnly(agent.ReadOnlyToolMode(cfg) != tools.ReadOnlyOff)
+	return store
+}
+
+// pinsChat returns --chat in the canonical form pins are stored under, so
+// legacy keys with a raw ":" in the chat ID find the same pins as the agent
+func pinsChat() string {
+	ref := agent.ParseSessionRef(pinsChatKey)
+	return agent.PinChatKey(ref.Channel, ref.AccountID, ref.ChatID)
+}
+
+func runPinsList(cmd *cobra.Command, args []string) {
+	pins, err := loadPinStore().List(pinsChat())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(pins) == 0 {
+		fmt.Printf("No pinned notes for %s\n", pinsChat())
+		return
+	}
+	for i, p := range pins {
+		fmt.Printf("%d. %s  (%s)\n", i+1, p.Text, p.CreatedAt.Format("2006-01-02 15:04"))
+	}
+}
+
+func runPinsAdd(cmd *cobra.Command, args []string) {
+	n, err := loadPinStore().Add(pinsChat(), strings.Join(args, " "))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Pinned #%d to %s\n", n, pinsChat())
+}
+
+func runPinsRm(cmd *cobra.Command, args []string) {
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid pin number: %s\n", args[0])
+		os.Exit(1)
+	}
+	removed, err := loadPinStore().Remove(pinsChat(), n)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Unpinned #%d: %s\n", n, removed.Text)
+}
@@ -155,12 +155,17 @@ func runStart(cmd *cobra.Command, args []string) {
 		logger.Fatal("Failed to get workspace path", zap.Error(err))
 	}
 
-	// 创建 workspace 管理器并确保文件存在
-	workspaceMgr := workspace.NewManager(workspaceDir)
-	if err := workspaceMgr.Ensure(); err != nil {
-		logger.Warn("Failed to ensure workspace files", zap.Error(err))
+	// 创建 workspace 管理器并确保文件存在（只读模式下不生成模板文件）
+	if cfg.ReadOnly.Enabled {
+		logger.Warn("Read-only mode enabled: mutating tools and internal writers are disabled",
+			zap.String("mutating_tools", string(agent.ReadOnlyToolMode(cfg))))
 	} else {
-		logger.Info("Workspace ready", zap.String("path", workspaceDir))
+		workspaceMgr := workspace.NewManager(workspaceDir)
+		if err := workspaceMgr.Ensure(); err != nil {
+			logger.Warn("Failed to ensure workspace files", zap.Error(err))
+		} else {
+			logger.Info("Workspace ready", zap.String("path", workspaceDir))
+		}
 	}
 
 	// 创建消息总线
@@ -197,6 +202,7 @@ func runStart(cmd *cobra.Command, args []string) {
 	// 创建工具注册表
 	toolRegistry := agent.NewToolRegistry()
 	contextBuilder.SetToolRegistry(toolRegistry)
+	agent.ApplyReadOnly(cfg, toolRegistry, contextBuilder)
 
 	// Runtime invalidator (tools call this; mainRuntime is assigned later).
 	var mainRuntime *agent.AgentSDKMainRuntime
@@ -338,6 +344,7 @@ func runStart(cmd *cobra.Command, args []string) {
 
 	// 创建网关服务器
 	gatewayServer := gateway.NewServer(&cfg.Gateway, messageBus, channelMgr, sessionMgr)
+	gatewayServer.SetReadOnly(cfg.ReadOnly.Enabled)
 	if err := gatewayServer.Start(ctx); err != nil {
 		logger.Warn("Failed to start gateway server", zap.Error(err))
 	}
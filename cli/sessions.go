@@ -10,6 +10,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/smallnest/goclaw/agent"
 	"github.com/smallnest/goclaw/config"
 	"github.com/smallnest/goclaw/session"
 	"github.com/spf13/cobra"
@@ -115,9 +116,9 @@ func runSessionsList(cmd *cobra.Command, args []string) {
 
 		// Extract channel and chat ID from key
 		if strings.Contains(key, ":") {
-			parts := strings.SplitN(key, ":", 2)
-			info.Channel = parts[0]
-			info.ChatID = parts[1]
+			ref := agent.ParseSessionRef(key)
+			info.Channel = ref.Channel
+			info.ChatID = ref.ChatID
 		}
 
 		// Get last message preview
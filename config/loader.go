@@ -78,6 +78,8 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("agents.defaults.inbound.max_concurrent", 4)
 	v.SetDefault("agents.defaults.inbound.queue_ack_interval_seconds", 3)
 	v.SetDefault("agents.defaults.inbound.session_idle_ttl_seconds", 600)
+	v.SetDefault("agents.defaults.tool_execution.max_parallel", 4)
+	v.SetDefault("agents.defaults.tool_execution.call_timeout_seconds", 0)
 	v.SetDefault("agents.defaults.subagents.max_concurrent", 8)
 	v.SetDefault("agents.defaults.subagents.role_max_concurrent", map[string]int{
 		"frontend": 5,
@@ -127,6 +129,10 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("memory.memsearch.sessions.redact", false)
 	v.SetDefault("memory.memsearch.context.enabled", false)
 	v.SetDefault("memory.memsearch.context.limit", 6)
+
+	// 只读模式默认配置
+	v.SetDefault("read_only.enabled", false)
+	v.SetDefault("read_only.mutating_tools", "hide")
 }
 
 // Save 保存配置到文件
@@ -230,6 +236,12 @@ func Validate(cfg *Config) error {
 		return fmt.Errorf("gateway config invalid: %w", err)
 	}
 
+	switch strings.ToLower(strings.TrimSpace(cfg.ReadOnly.MutatingTools)) {
+	case "", "hide", "error":
+	default:
+		return fmt.Errorf("read_only.mutating_tools must be hide or error")
+	}
+
 	return nil
 }
 
@@ -263,6 +275,12 @@ func validateAgents(cfg *Config) error {
 	if cfg.Agents.Defaults.History.AgentsdkCleanupDays < 0 {
 		return fmt.Errorf("agents.defaults.history.agentsdk_cleanup_days must be non-negative")
 	}
+	if cfg.Agents.Defaults.ToolExecution.MaxParallel < 0 {
+		return fmt.Errorf("agents.defaults.tool_execution.max_parallel must be non-negative")
+	}
+	if cfg.Agents.Defaults.ToolExecution.CallTimeoutSeconds < 0 {
+		return fmt.Errorf("agents.defaults.tool_execution.call_timeout_seconds must be non-negative")
+	}
 
 	return nil
 }
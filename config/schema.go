@@ -14,6 +14,7 @@ type Config struct {
 	Tools     ToolsConfig     `mapstructure:"tools" json:"tools"`
 	Approvals ApprovalsConfig `mapstructure:"approvals" json:"approvals"`
 	Memory    MemoryConfig    `mapstructure:"memory" json:"memory"`
+	ReadOnly  ReadOnlyConfig  `mapstructure:"read_only" json:"read_only"`
 	// Skills configuration (map[string]interface{} to be parsed by skills package)
 	Skills map[string]interface{} `mapstructure:"skills" json:"skills"`
 	// Agent 绑定配置
@@ -33,13 +34,14 @@ type AgentsConfig struct {
 
 // AgentDefaults Agent 默认配置
 type AgentDefaults struct {
-	Model         string             `mapstructure:"model" json:"model"`
-	MaxIterations int                `mapstructure:"max_iterations" json:"max_iterations"`
-	Temperature   float64            `mapstructure:"temperature" json:"temperature"`
-	MaxTokens     int                `mapstructure:"max_tokens" json:"max_tokens"`
-	Inbound       InboundConfig      `mapstructure:"inbound" json:"inbound"`
-	Subagents     *SubagentsConfig   `mapstructure:"subagents" json:"subagents"`
-	History       AgentHistoryConfig `mapstructure:"history" json:"history"`
+	Model         string              `mapstructure:"model" json:"model"`
+	MaxIterations int                 `mapstructure:"max_iterations" json:"max_iterations"`
+	Temperature   float64             `mapstructure:"temperature" json:"temperature"`
+	MaxTokens     int                 `mapstructure:"max_tokens" json:"max_tokens"`
+	Inbound       InboundConfig       `mapstructure:"inbound" json:"inbound"`
+	Subagents     *SubagentsConfig    `mapstructure:"subagents" json:"subagents"`
+	History       AgentHistoryConfig  `mapstructure:"history" json:"history"`
+	ToolExecution ToolExecutionConfig `mapstructure:"tool_execution" json:"tool_execution"`
 }
 
 // InboundConfig controls how inbound chat messages are dispatched and processed.
@@ -54,6 +56,15 @@ type InboundConfig struct {
 	SessionIdleTTLSeconds int `mapstructure:"session_idle_ttl_seconds" json:"session_idle_ttl_seconds"`
 }
 
+// ToolExecutionConfig controls how the tool calls of one model turn are dispatched.
+type ToolExecutionConfig struct {
+	// MaxParallel limits how many tool calls of one run (a user turn of one
+	// session) execute concurrently. 1 runs them sequentially; 0 uses the built-in default.
+	MaxParallel int `mapstructure:"max_parallel" json:"max_parallel"`
+	// CallTimeoutSeconds bounds each tool call individually. 0 means no per-call limit.
+	CallTimeoutSeconds int `mapstructure:"call_timeout_seconds" json:"call_timeout_seconds"`
+}
+
 // SubagentsConfig 分身配置
 type SubagentsConfig struct {
 	MaxConcurrent       int            `mapstructure:"max_concurrent" json:"max_concurrent"`
@@ -343,6 +354,13 @@ type ApprovalsConfig struct {
 	Allowlist []string `mapstructure:"allowlist" json:"allowlist"` // 工具允许列表
 }
 
+// ReadOnlyConfig 只读模式配置（演示/展台部署）
+type ReadOnlyConfig struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// MutatingTools 修改类工具的处理方式: "hide" 从工具列表隐藏, "error" 保留但调用返回只读错误
+	MutatingTools string `mapstructure:"mutating_tools" json:"mutating_tools"`
+}
+
 // MemoryConfig 记忆配置
 type MemoryConfig struct {
 	Backend   string              `mapstructure:"backend" json:"backend"` // "builtin" | "qmd" | "memsearch"
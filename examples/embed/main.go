@@ -0,0 +1,70 @@
+// Command embed shows how to run a goclaw agent inside another Go program.
+//
+//	go run ./examples/embed "what time is it?"
+//
+// Provider credentials come from the regular goclaw config
+// (~/.goclaw/config.json or ./config.json).
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/smallnest/goclaw/pkg/goclaw"
+)
+
+func main() {
+	prompt := "What time is it? Use the clock tool."
+	if len(os.Args) > 1 {
+		prompt = strings.Join(os.Args[1:], " ")
+	}
+
+	client, err := goclaw.New(nil,
+		goclaw.WithChannel("embed", "example"),
+		goclaw.WithMessageHandler(func(msg goclaw.Message) {
+			fmt.Printf("\n[async %s] %s\n", msg.ChatID, msg.Content)
+		}),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create client: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = client.Close() }()
+
+	clock := goclaw.NewTool(
+		"clock",
+		"Return the current local time in RFC3339 format.",
+		map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+		func(ctx context.Context, params map[string]interface{}) (string, error) {
+			return time.Now().Format(time.RFC3339), nil
+		},
+	)
+	if err := client.RegisterTool(clock); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to register tool: %v\n", err)
+		os.Exit(1)
+	}
+
+	sessionKey, err := client.NewSession("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create session: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	resp, err := client.SendStream(ctx, sessionKey, prompt, func(delta string) {
+		fmt.Print(delta)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nAgent execution failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\n\nsession: %s (%d chars)\n", resp.SessionKey, len(resp.Output))
+}
@@ -24,6 +24,7 @@ type Handler struct {
 	channelMgr *channels.Manager
 	agentMgr   *agent.AgentManager
 	notifier   SessionNotifier
+	readOnly   bool
 }
 
 // NewHandler 创建处理器
@@ -55,6 +56,11 @@ func (h *Handler) SetAgentManager(manager *agent.AgentManager) {
 	h.agentMgr = manager
 }
 
+// SetReadOnly 设置只读模式
+func (h *Handler) SetReadOnly(readOnly bool) {
+	h.readOnly = readOnly
+}
+
 // SetNotifier injects a session notifier for streaming events.
 func (h *Handler) SetNotifier(notifier SessionNotifier) {
 	h.notifier = notifier
@@ -105,6 +111,9 @@ func (h *Handler) registerSystemMethods() {
 
 	// config.set - 设置配置
 	h.registry.Register("config.set", func(sessionID string, params map[string]interface{}) (interface{}, error) {
+		if h.readOnly {
+			return nil, fmt.Errorf("read-only mode: config.set is disabled")
+		}
 		key, _ := params["key"].(string)
 		value := params["value"]
 		// 这里应该更新配置
@@ -120,6 +129,7 @@ func (h *Handler) registerSystemMethods() {
 			"status":    "ok",
 			"timestamp": time.Now().Unix(),
 			"version":   ProtocolVersion,
+			"read_only": h.readOnly,
 		}, nil
 	})
 
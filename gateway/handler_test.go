@@ -171,3 +171,32 @@ func TestHandleRequestAgentMissingContentReturnsInvalidParams(t *testing.T) {
 		t.Fatalf("expected invalid params code %d, got %d", ErrorInvalidParams, resp.Error.Code)
 	}
 }
+
+func TestHandleRequestConfigSetRejectedInReadOnlyMode(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetReadOnly(true)
+
+	resp := h.HandleRequest("s1", &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "5",
+		Method:  "config.set",
+		Params: map[string]interface{}{
+			"key":   "agents.defaults.model",
+			"value": "x",
+		},
+	})
+	if resp == nil || resp.Error == nil {
+		t.Fatalf("expected config.set to fail in read-only mode")
+	}
+	if !strings.Contains(resp.Error.Message, "read-only") {
+		t.Fatalf("expected read-only error, got: %v", resp.Error.Message)
+	}
+
+	resp = h.HandleRequest("s1", &JSONRPCRequest{JSONRPC: "2.0", ID: "6", Method: "health"})
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("health failed: %+v", resp)
+	}
+	if result, _ := resp.Result.(map[string]interface{}); result["read_only"] != true {
+		t.Fatalf("health should report read_only, got %+v", resp.Result)
+	}
+}
@@ -48,6 +48,7 @@ type Server struct {
 	enableAuth    bool
 	authToken     string
 	agentMgr      *agent.AgentManager
+	readOnly      bool
 }
 
 // WebSocketConfig WebSocket 配置
@@ -105,6 +106,16 @@ func (s *Server) SetWebSocketConfig(cfg *WebSocketConfig) {
 	s.authToken = cfg.AuthToken
 }
 
+// SetReadOnly 标记只读模式：健康检查会报告该状态，配置修改被拒绝
+func (s *Server) SetReadOnly(readOnly bool) {
+	s.mu.Lock()
+	s.readOnly = readOnly
+	s.mu.Unlock()
+	if s.handler != nil {
+		s.handler.SetReadOnly(readOnly)
+	}
+}
+
 // SetAgentManager injects an agent manager for streaming requests.
 func (s *Server) SetAgentManager(manager *agent.AgentManager) {
 	s.mu.Lock()
@@ -330,9 +341,14 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
+	s.mu.RLock()
+	readOnly := s.readOnly
+	s.mu.RUnlock()
+
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "ok",
-		"time":   time.Now().Unix(),
+		"status":    "ok",
+		"time":      time.Now().Unix(),
+		"read_only": readOnly,
 	})
 }
 
@@ -0,0 +1,343 @@
+package htmlmd
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// Elements that never carry readable content.
+	droppedElements = map[atom.Atom]bool{
+		atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+		atom.Svg: true, atom.Canvas: true, atom.Iframe: true, atom.Object: true,
+		atom.Embed: true, atom.Link: true, atom.Meta: true, atom.Head: true,
+		atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+		atom.Nav: true, atom.Footer: true, atom.Aside: true, atom.Dialog: true,
+	}
+
+	boilerplateRoles = map[string]bool{
+		"navigation":    true,
+		"banner":        true,
+		"contentinfo":   true,
+		"complementary": true,
+		"search":        true,
+		"dialog":        true,
+	}
+
+	unlikelyCandidates = regexp.MustCompile(`(?i)-ad-|ai2html|banner|breadcrumb|combx|comment|community|cookie|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|navbar|newsletter|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|supplemental|ad-break|agegate|pagination|pager|popup|modal|yom-remote`)
+	maybeCandidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveWeight     = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeWeight     = regexp.MustCompile(`(?i)hidden|banner|combx|comment|com-|contact|foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+
+	// Descendants that stop a <div> from being scored like a paragraph.
+	blockDescendants = map[atom.Atom]bool{
+		atom.Blockquote: true, atom.Dl: true, atom.Div: true, atom.Img: true,
+		atom.Ol: true, atom.P: true, atom.Pre: true, atom.Table: true, atom.Ul: true,
+		atom.Section: true, atom.Article: true, atom.H1: true, atom.H2: true,
+		atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	}
+)
+
+// minMainChars is how much text an explicit <main>/<article> needs before it
+// is trusted without scoring.
+const minMainChars = 140
+
+// stripBoilerplate removes non-content elements below root. When aggressive
+// is set, elements whose class/id look like page chrome are dropped as well.
+func stripBoilerplate(root *html.Node, aggressive bool) {
+	var doomed []*html.Node
+	var walk func(n *html.Node, inContent bool)
+	walk = func(n *html.Node, inContent bool) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Type {
+			case html.CommentNode:
+				doomed = append(doomed, c)
+				continue
+			case html.ElementNode:
+			default:
+				continue
+			}
+			if isBoilerplate(c, aggressive, inContent) {
+				doomed = append(doomed, c)
+				continue
+			}
+			walk(c, inContent || c.DataAtom == atom.Article || c.DataAtom == atom.Main)
+		}
+	}
+	walk(root, false)
+	for _, n := range doomed {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+func isBoilerplate(n *html.Node, aggressive, inContent bool) bool {
+	if droppedElements[n.DataAtom] {
+		return true
+	}
+	// A page-level <header> is site chrome; inside an article it holds the title.
+	if n.DataAtom == atom.Header && !inContent {
+		return true
+	}
+	if _, ok := attrOK(n, "hidden"); ok {
+		return true
+	}
+	if strings.EqualFold(attr(n, "aria-hidden"), "true") {
+		return true
+	}
+	if style := strings.ToLower(strings.ReplaceAll(attr(n, "style"), " ", "")); strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+		return true
+	}
+	if boilerplateRoles[strings.ToLower(attr(n, "role"))] {
+		return true
+	}
+	if !aggressive {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.Body, atom.Main, atom.Article, atom.A, atom.Table, atom.Tbody, atom.Tr, atom.Td, atom.Th, atom.Pre, atom.Code:
+		return false
+	}
+	match := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyCandidates.MatchString(match) && !maybeCandidate.MatchString(match)
+}
+
+// extractMain picks the node(s) holding the page's main content. It returns
+// nil when nothing stands out, in which case the whole body is rendered.
+func extractMain(body *html.Node) []*html.Node {
+	if n := singleExplicitMain(body); n != nil {
+		return []*html.Node{n}
+	}
+
+	scores := map[*html.Node]float64{}
+	var order []*html.Node
+	initCandidate := func(n *html.Node) {
+		if _, ok := scores[n]; ok {
+			return
+		}
+		scores[n] = tagWeight(n) + classWeight(n)
+		order = append(order, n)
+	}
+
+	walkElements(body, func(n *html.Node) {
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td:
+		case atom.Div:
+			if hasDescendant(n, blockDescendants) {
+				return
+			}
+		default:
+			return
+		}
+		text := collapseSpace(strings.TrimSpace(textContent(n)))
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			return
+		}
+		score := 1 + float64(countCommas(text)) + min(float64(length)/100, 3)
+
+		parent := n.Parent
+		if parent == nil || parent.Type != html.ElementNode {
+			return
+		}
+		initCandidate(parent)
+		scores[parent] += score
+		if gp := parent.Parent; gp != nil && gp.Type == html.ElementNode {
+			initCandidate(gp)
+			scores[gp] += score / 2
+		}
+	})
+
+	var top *html.Node
+	for _, n := range order {
+		scores[n] *= 1 - linkDensity(n)
+		if top == nil || scores[n] > scores[top] {
+			top = n
+		}
+	}
+	if top == nil || top == body || top.Parent == nil {
+		return nil
+	}
+
+	// Pull in siblings that look like part of the same article.
+	threshold := max(10, scores[top]*0.2)
+	var picked []*html.Node
+	for sib := top.Parent.FirstChild; sib != nil; sib = sib.NextSibling {
+		if sib.Type != html.ElementNode {
+			continue
+		}
+		if sib == top {
+			picked = append(picked, sib)
+			continue
+		}
+		if s, ok := scores[sib]; ok && s >= threshold {
+			picked = append(picked, sib)
+			continue
+		}
+		if sib.DataAtom == atom.P {
+			text := collapseSpace(strings.TrimSpace(textContent(sib)))
+			if utf8.RuneCountInString(text) > 80 && linkDensity(sib) < 0.25 {
+				picked = append(picked, sib)
+			}
+		}
+	}
+	return picked
+}
+
+func singleExplicitMain(body *html.Node) *html.Node {
+	var mains, articles []*html.Node
+	walkElements(body, func(n *html.Node) {
+		switch {
+		case n.DataAtom == atom.Main, strings.EqualFold(attr(n, "role"), "main"):
+			mains = append(mains, n)
+		case n.DataAtom == atom.Article:
+			articles = append(articles, n)
+		}
+	})
+	for _, group := range [][]*html.Node{mains, articles} {
+		if len(group) != 1 {
+			continue
+		}
+		if utf8.RuneCountInString(collapseSpace(textContent(group[0]))) >= minMainChars {
+			return group[0]
+		}
+	}
+	return nil
+}
+
+func tagWeight(n *html.Node) float64 {
+	switch n.DataAtom {
+	case atom.Div, atom.Article, atom.Main, atom.Section:
+		return 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		return 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		return -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		return -5
+	}
+	return 0
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, v := range []string{attr(n, "class"), attr(n, "id")} {
+		if v == "" {
+			continue
+		}
+		if negativeWeight.MatchString(v) {
+			weight -= 25
+		}
+		if positiveWeight.MatchString(v) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := utf8.RuneCountInString(collapseSpace(textContent(n)))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	walkElements(n, func(c *html.Node) {
+		if c.DataAtom == atom.A {
+			linked += utf8.RuneCountInString(collapseSpace(textContent(c)))
+		}
+	})
+	return float64(linked) / float64(total)
+}
+
+func countCommas(text string) int {
+	n := 0
+	for _, r := range text {
+		switch r {
+		case ',', '，', '、':
+			n++
+		}
+	}
+	return n
+}
+
+// walkElements visits every element below n (not n itself) in document order.
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			fn(c)
+			walkElements(c, fn)
+		}
+	}
+}
+
+func hasDescendant(n *html.Node, set map[atom.Atom]bool) bool {
+	found := false
+	walkElements(n, func(c *html.Node) {
+		if set[c.DataAtom] {
+			found = true
+		}
+	})
+	return found
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findFirst(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
+
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrOK(n, key)
+	return v
+}
+
+// collapseSpace folds runs of HTML whitespace into a single space.
+func collapseSpace(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	space := false
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !space {
+				sb.WriteByte(' ')
+			}
+			space = true
+		default:
+			sb.WriteRune(r)
+			space = false
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,196 @@
+// Package htmlmd converts HTML pages into compact Markdown for model input.
+//
+// Conversion strips scripts, styles and navigation boilerplate, optionally
+// extracts the main content block with a readability-style scorer, and renders
+// headings, lists, tables, links (resolved to absolute URLs), images and code
+// blocks as GitHub-flavoured Markdown.
+package htmlmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultMaxChars is the size limit callers use when they have no better one.
+const DefaultMaxChars = 20000
+
+// Options controls a conversion.
+type Options struct {
+	// BaseURL resolves relative links and image sources. A <base href> in the
+	// document is applied on top of it.
+	BaseURL string
+	// MaxChars truncates the Markdown output (in characters, not bytes).
+	// Zero or negative disables truncation.
+	MaxChars int
+	// FullPage skips main-content extraction. Boilerplate such as scripts,
+	// styles and navigation is still removed.
+	FullPage bool
+}
+
+// Convert renders src as Markdown according to opts.
+func Convert(src string, opts Options) (string, error) {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return "", fmt.Errorf("parse html: %w", err)
+	}
+
+	title := documentTitle(doc)
+	base := resolveBase(doc, opts.BaseURL)
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+	stripBoilerplate(body, !opts.FullPage)
+
+	roots := []*html.Node{body}
+	if !opts.FullPage {
+		if main := extractMain(body); len(main) > 0 {
+			roots = main
+		}
+	}
+
+	r := &renderer{base: base}
+	var sb strings.Builder
+	for _, n := range roots {
+		sb.WriteString(r.block(r.children(n)))
+	}
+	md := normalize(sb.String())
+
+	if title != "" && !strings.HasPrefix(md, "# ") {
+		md = strings.TrimSpace("# " + title + "\n\n" + md)
+	}
+	return Truncate(md, opts.MaxChars), nil
+}
+
+// Link renders an inline Markdown link, escaping text and href the same way
+// links of converted pages are, for callers that assemble Markdown themselves.
+func Link(text, href string) string {
+	return "[" + escapeLinkText(oneLine(text)) + "](" + linkDestination(href) + ")"
+}
+
+// Truncate cuts md to at most maxChars characters and appends a
+// "content truncated at N chars" marker. An open code fence is closed so the
+// remainder still parses. maxChars <= 0 returns md unchanged.
+func Truncate(md string, maxChars int) string {
+	if maxChars <= 0 || utf8.RuneCountInString(md) <= maxChars {
+		return md
+	}
+
+	offset := 0
+	for i := 0; i < maxChars; i++ {
+		_, size := utf8.DecodeRuneInString(md[offset:])
+		offset += size
+	}
+	cut := md[:offset]
+
+	// Prefer a line boundary when it does not throw away too much.
+	if i := strings.LastIndex(cut, "\n"); i > 0 && utf8.RuneCountInString(cut[:i]) >= maxChars*4/5 {
+		cut = cut[:i]
+	}
+	cut = strings.TrimRight(cut, " \t\n")
+
+	if fence := openFence(cut); fence != "" {
+		cut += "\n" + fence
+	}
+	return cut + fmt.Sprintf("\n\n[content truncated at %d chars]", maxChars)
+}
+
+// openFence returns the fence marker left unclosed in md, if any.
+func openFence(md string) string {
+	open := ""
+	for _, line := range strings.Split(md, "\n") {
+		marker := fenceMarker(line)
+		if marker == "" {
+			continue
+		}
+		switch {
+		case open == "":
+			open = marker
+		case strings.HasPrefix(marker, open):
+			open = ""
+		}
+	}
+	return open
+}
+
+func fenceMarker(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	for _, ch := range []string{"`", "~"} {
+		if strings.HasPrefix(trimmed, ch+ch+ch) {
+			n := 0
+			for n < len(trimmed) && trimmed[n] == ch[0] {
+				n++
+			}
+			return trimmed[:n]
+		}
+	}
+	return ""
+}
+
+// normalize trims trailing whitespace and collapses blank-line runs outside
+// of fenced code blocks.
+func normalize(md string) string {
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	fence := ""
+	blank := false
+	for _, line := range lines {
+		if fence != "" {
+			out = append(out, line)
+			if m := fenceMarker(line); m != "" && strings.HasPrefix(m, fence) && strings.TrimSpace(line) == m {
+				fence = ""
+			}
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		if m := fenceMarker(line); m != "" {
+			fence = m
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func documentTitle(doc *html.Node) string {
+	t := findFirst(doc, atom.Title)
+	if t == nil {
+		return ""
+	}
+	return collapseSpace(strings.TrimSpace(textContent(t)))
+}
+
+func resolveBase(doc *html.Node, baseURL string) *url.URL {
+	var base *url.URL
+	if strings.TrimSpace(baseURL) != "" {
+		if u, err := url.Parse(strings.TrimSpace(baseURL)); err == nil {
+			base = u
+		}
+	}
+	if b := findFirst(doc, atom.Base); b != nil {
+		if href := strings.TrimSpace(attr(b, "href")); href != "" {
+			if u, err := url.Parse(href); err == nil {
+				if base != nil {
+					u = base.ResolveReference(u)
+				}
+				if u.IsAbs() {
+					base = u
+				}
+			}
+		}
+	}
+	return base
+}
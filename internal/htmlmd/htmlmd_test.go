@@ -0,0 +1,184 @@
+package htmlmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.md golden files")
+
+func TestConvertFixtures(t *testing.T) {
+	cases := []struct {
+		name string
+		opts Options
+	}{
+		{name: "nested_tables"},
+		{name: "lazy_images", opts: Options{BaseURL: "https://example.com/photos/"}},
+		{name: "cjk", opts: Options{BaseURL: "https://blog.example.cn/posts/1"}},
+		{name: "code_blocks"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			src, err := os.ReadFile(filepath.Join("testdata", tc.name+".html"))
+			if err != nil {
+				t.Fatalf("read fixture: %v", err)
+			}
+			got, err := Convert(string(src), tc.opts)
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+
+			golden := filepath.Join("testdata", tc.name+".md")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got+"\n"), 0644); err != nil {
+					t.Fatalf("write golden: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden: %v", err)
+			}
+			if got != strings.TrimSuffix(string(want), "\n") {
+				t.Errorf("output mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestConvertDropsBoilerplate(t *testing.T) {
+	src := `<html><body>
+<nav><a href="/">Home</a></nav>
+<div class="cookie-banner">We use cookies</div>
+<script>alert(1)</script>
+<div class="content"><p>This paragraph is the actual body of the page, long enough to win the scoring, with a comma or two.</p></div>
+<div class="related"><p><a href="/a">Related one</a>, <a href="/b">Related two</a>, <a href="/c">Related three</a></p></div>
+</body></html>`
+
+	got, err := Convert(src, Options{})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	for _, unwanted := range []string{"Home", "cookies", "alert", "Related"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("expected %q to be dropped, got:\n%s", unwanted, got)
+		}
+	}
+	if !strings.Contains(got, "actual body of the page") {
+		t.Errorf("main content missing, got:\n%s", got)
+	}
+
+	full, err := Convert(src, Options{FullPage: true})
+	if err != nil {
+		t.Fatalf("Convert(FullPage): %v", err)
+	}
+	if !strings.Contains(full, "[Related one](/a)") {
+		t.Errorf("full page conversion should keep non-nav content, got:\n%s", full)
+	}
+	if strings.Contains(full, "alert") || strings.Contains(full, "Home") {
+		t.Errorf("full page conversion should still drop scripts and nav, got:\n%s", full)
+	}
+}
+
+func TestConvertTruncates(t *testing.T) {
+	src := "<html><body><p>" + strings.Repeat("内容很长的段落。", 500) + "</p></body></html>"
+	got, err := Convert(src, Options{MaxChars: 100})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if !strings.HasSuffix(got, "[content truncated at 100 chars]") {
+		t.Fatalf("expected truncation marker, got %q", got)
+	}
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncation split a multi-byte character: %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		max      int
+		want     string
+		contains []string
+	}{
+		{name: "short input untouched", in: "hello", max: 10, want: "hello"},
+		{name: "disabled", in: "hello", max: 0, want: "hello"},
+		{
+			name: "cjk counts runes",
+			in:   "你好世界你好世界",
+			max:  4,
+			want: "你好世界\n\n[content truncated at 4 chars]",
+		},
+		{
+			name: "prefers line boundary",
+			in:   "line one is here\nline two is here",
+			max:  20,
+			want: "line one is here\n\n[content truncated at 20 chars]",
+		},
+		{
+			name:     "closes open fence",
+			in:       "intro\n\n```go\nfunc a() {}\nfunc b() {}\nfunc c() {}\n```",
+			max:      30,
+			contains: []string{"```go\n", "\n```\n\n[content truncated at 30 chars]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.in, tt.max)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("Truncate() = %q, want %q", got, tt.want)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("Truncate() = %q, missing %q", got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertEscapesLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "brackets in text",
+			in:   `<a href="https://example.com/r">[v1.2] release</a>`,
+			want: `[\[v1.2\] release](https://example.com/r)`,
+		},
+		{
+			name: "balanced parentheses kept",
+			in:   `<a href="https://en.wikipedia.org/wiki/Go_(programming_language)">Go</a>`,
+			want: `[Go](https://en.wikipedia.org/wiki/Go_(programming_language))`,
+		},
+		{
+			name: "unbalanced parentheses encoded",
+			in:   `<a href="https://example.com/a)b">odd</a>`,
+			want: `[odd](https://example.com/a%29b)`,
+		},
+		{
+			name: "image alt",
+			in:   `<img src="https://example.com/x.png" alt="[logo]">`,
+			want: `![\[logo\]](https://example.com/x.png)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert("<html><body><p>"+tt.in+"</p></body></html>", Options{FullPage: true})
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,477 @@
+package htmlmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// lazyImageAttrs lists attributes lazy-loading scripts keep the real source in.
+var lazyImageAttrs = []string{"data-src", "data-original", "data-lazy-src", "data-actualsrc", "data-url"}
+
+type renderer struct {
+	base *url.URL
+}
+
+// children renders the child nodes of n, dropping indentation that would
+// otherwise follow a block boundary and doubled spaces left by removed nodes.
+func (r *renderer) children(n *html.Node) string {
+	var sb strings.Builder
+	var last byte
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		s := r.node(c)
+		if last == '\n' || last == ' ' || sb.Len() == 0 && c.Type == html.TextNode {
+			s = strings.TrimLeft(s, " ")
+		}
+		if s == "" {
+			continue
+		}
+		sb.WriteString(s)
+		last = s[len(s)-1]
+	}
+	return sb.String()
+}
+
+func (r *renderer) node(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return collapseSpace(n.Data)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := oneLine(r.children(n))
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + text + "\n\n"
+	case atom.Br:
+		return "\n"
+	case atom.Hr:
+		return "\n\n---\n\n"
+	case atom.Strong, atom.B:
+		return wrapInline(r.children(n), "**")
+	case atom.Em, atom.I:
+		return wrapInline(r.children(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrapInline(r.children(n), "~~")
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		return inlineCode(textContent(n))
+	case atom.Pre:
+		return r.pre(n)
+	case atom.A:
+		return r.link(n)
+	case atom.Img:
+		return r.image(n)
+	case atom.Ul:
+		return r.list(n, false)
+	case atom.Ol:
+		return r.list(n, true)
+	case atom.Blockquote:
+		return r.blockquote(n)
+	case atom.Table:
+		return r.table(n)
+	case atom.Dt:
+		return "\n\n" + wrapInline(oneLine(r.children(n)), "**") + "\n"
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header,
+		atom.Figure, atom.Figcaption, atom.Address, atom.Details, atom.Summary,
+		atom.Dl, atom.Dd, atom.Center, atom.Body, atom.Li:
+		return r.block(r.children(n))
+	}
+	return r.children(n)
+}
+
+func (r *renderer) block(inner string) string {
+	inner = strings.TrimSpace(inner)
+	if inner == "" {
+		return ""
+	}
+	return "\n\n" + inner + "\n\n"
+}
+
+func (r *renderer) pre(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		switch {
+		case c.Type == html.TextNode:
+			sb.WriteString(c.Data)
+		case c.Type == html.ElementNode && c.DataAtom == atom.Br:
+			sb.WriteString("\n")
+		default:
+			for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+				walk(cc)
+			}
+		}
+	}
+	walk(n)
+
+	code := strings.TrimRight(strings.TrimLeft(sb.String(), "\n"), " \t\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+	fence := "```"
+	if strings.Contains(code, "```") {
+		fence = "~~~~"
+	}
+	return "\n\n" + fence + codeLanguage(n) + "\n" + code + "\n" + fence + "\n\n"
+}
+
+func codeLanguage(pre *html.Node) string {
+	classes := attr(pre, "class")
+	for c := pre.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Code {
+			classes += " " + attr(c, "class")
+		}
+	}
+	for _, class := range strings.Fields(classes) {
+		for _, prefix := range []string{"language-", "lang-", "highlight-source-"} {
+			if strings.HasPrefix(class, prefix) && len(class) > len(prefix) {
+				return class[len(prefix):]
+			}
+		}
+	}
+	return ""
+}
+
+func (r *renderer) link(n *html.Node) string {
+	text := oneLine(r.children(n))
+	href := strings.TrimSpace(attr(n, "href"))
+	if text == "" {
+		return ""
+	}
+	lower := strings.ToLower(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(lower, "javascript:") {
+		return text
+	}
+	return "[" + escapeLinkText(text) + "](" + linkDestination(r.resolve(href)) + ")"
+}
+
+func (r *renderer) image(n *html.Node) string {
+	if attr(n, "width") == "1" && attr(n, "height") == "1" {
+		return ""
+	}
+	src := imageSource(n)
+	if src == "" {
+		return ""
+	}
+	alt := oneLine(collapseSpace(attr(n, "alt")))
+	return "![" + escapeLinkText(alt) + "](" + linkDestination(r.resolve(src)) + ")"
+}
+
+// imageSource prefers lazy-load attributes over the (usually placeholder) src.
+func imageSource(n *html.Node) string {
+	for _, key := range lazyImageAttrs {
+		if v := strings.TrimSpace(attr(n, key)); v != "" && !isPlaceholderImage(v) {
+			return v
+		}
+	}
+	if src := strings.TrimSpace(attr(n, "src")); src != "" && !isPlaceholderImage(src) {
+		return src
+	}
+	for _, key := range []string{"data-srcset", "srcset"} {
+		if v := firstSrcsetURL(attr(n, key)); v != "" && !isPlaceholderImage(v) {
+			return v
+		}
+	}
+	return ""
+}
+
+func isPlaceholderImage(src string) bool {
+	lower := strings.ToLower(src)
+	if strings.HasPrefix(lower, "data:") {
+		return true
+	}
+	for _, marker := range []string{"blank.gif", "spacer.gif", "pixel.gif", "placeholder", "lazy.gif", "loading.gif"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstSrcsetURL(srcset string) string {
+	first, _, _ := strings.Cut(strings.TrimSpace(srcset), ",")
+	fields := strings.Fields(first)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func (r *renderer) list(n *html.Node, ordered bool) string {
+	index := 1
+	if ordered {
+		if start, err := strconv.Atoi(strings.TrimSpace(attr(n, "start"))); err == nil {
+			index = start
+		}
+	}
+
+	var items []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		body := tightLines(strings.TrimSpace(r.children(c)))
+		if body == "" {
+			continue
+		}
+		if c.DataAtom != atom.Li {
+			// Stray nested lists directly under <ul>/<ol> belong to the previous item.
+			if len(items) > 0 {
+				items[len(items)-1] += "\n" + indentLines(body, "  ")
+			} else {
+				items = append(items, body)
+			}
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+		items = append(items, marker+indentLines(body, strings.Repeat(" ", len(marker)))[len(marker):])
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(items, "\n") + "\n\n"
+}
+
+func (r *renderer) blockquote(n *html.Node) string {
+	inner := strings.TrimSpace(normalize(r.children(n)))
+	if inner == "" {
+		return ""
+	}
+	lines := strings.Split(inner, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+}
+
+func (r *renderer) table(n *html.Node) string {
+	rows := tableRows(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	// Tables used for layout (nesting other tables, or a single column) are
+	// flattened: each cell becomes its own block.
+	cols := 0
+	for _, row := range rows {
+		width := 0
+		for _, cell := range row {
+			width += colspan(cell)
+		}
+		cols = max(cols, width)
+	}
+	if cols <= 1 || hasDescendant(n, map[atom.Atom]bool{atom.Table: true}) {
+		var sb strings.Builder
+		for _, row := range rows {
+			for _, cell := range row {
+				sb.WriteString(r.block(r.children(cell)))
+			}
+		}
+		return sb.String()
+	}
+
+	var lines []string
+	if caption := findFirst(n, atom.Caption); caption != nil {
+		if text := oneLine(r.children(caption)); text != "" {
+			lines = append(lines, "**"+text+"**", "")
+		}
+	}
+	for i, row := range rows {
+		cells := make([]string, 0, cols)
+		for _, cell := range row {
+			text := strings.ReplaceAll(oneLine(r.children(cell)), "|", `\|`)
+			cells = append(cells, text)
+			for span := colspan(cell); span > 1; span-- {
+				cells = append(cells, "")
+			}
+		}
+		for len(cells) < cols {
+			cells = append(cells, "")
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", cols))
+		}
+	}
+	return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+}
+
+// tableRows returns the cells of every row that belongs to table t itself
+// (rows of nested tables are left to the nested table).
+func tableRows(t *html.Node) [][]*html.Node {
+	var rows [][]*html.Node
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(c)
+			case atom.Tr:
+				var cells []*html.Node
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						cells = append(cells, cell)
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			}
+		}
+	}
+	collect(t)
+	return rows
+}
+
+func colspan(cell *html.Node) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(attr(cell, "colspan"))); err == nil && n > 1 && n <= 100 {
+		return n
+	}
+	return 1
+}
+
+func (r *renderer) resolve(ref string) string {
+	if r.base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return r.base.ResolveReference(u).String()
+}
+
+var linkTextEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`)
+
+// escapeLinkText escapes brackets so titles like "[v1.2] release" keep the
+// link text delimited.
+func escapeLinkText(text string) string {
+	return linkTextEscaper.Replace(text)
+}
+
+// linkDestination makes a URL safe as an inline link destination. Balanced
+// parentheses (e.g. Wikipedia's "Go_(programming_language)") are valid
+// CommonMark and stay readable; unbalanced ones and whitespace are
+// percent-encoded so the destination never ends early.
+func linkDestination(u string) string {
+	depth, balanced := 0, true
+	for _, c := range u {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				balanced = false
+			}
+		}
+	}
+	if depth != 0 {
+		balanced = false
+	}
+
+	var b strings.Builder
+	b.Grow(len(u))
+	for _, c := range u {
+		switch {
+		case c == ' ':
+			b.WriteString("%20")
+		case c == '<':
+			b.WriteString("%3C")
+		case c == '>':
+			b.WriteString("%3E")
+		case c == '(' && !balanced:
+			b.WriteString("%28")
+		case c == ')' && !balanced:
+			b.WriteString("%29")
+		case c < ' ':
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func wrapInline(inner, marker string) string {
+	trimmed := strings.TrimSpace(inner)
+	if trimmed == "" {
+		return inner
+	}
+	lead, trail := "", ""
+	if strings.HasPrefix(inner, " ") {
+		lead = " "
+	}
+	if strings.HasSuffix(inner, " ") {
+		trail = " "
+	}
+	return lead + marker + trimmed + marker + trail
+}
+
+func inlineCode(text string) string {
+	text = collapseSpace(text)
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func indentLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tightLines drops blank lines outside fenced code so list items stay compact.
+func tightLines(s string) string {
+	lines := strings.Split(normalize(s), "\n")
+	out := lines[:0]
+	fence := ""
+	for _, line := range lines {
+		if m := fenceMarker(line); m != "" {
+			switch {
+			case fence == "":
+				fence = m
+			case strings.HasPrefix(m, fence):
+				fence = ""
+			}
+		}
+		if line == "" && fence == "" {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,92 @@
+// Package keylock provides mutual exclusion per string key.
+//
+// Waiters for a key are served in arrival order, a wait can be abandoned by
+// cancelling its context, and keys nobody holds or waits for are dropped, so
+// memory only grows with the number of keys in use at the same time.
+package keylock
+
+import (
+	"context"
+	"sync"
+)
+
+// Locker is a set of FIFO mutexes created on demand per key. The zero value
+// is not usable; create one with New.
+type Locker struct {
+	mu   sync.Mutex
+	keys map[string]*entry
+}
+
+// entry is the state of a held key; waiters are woken in order by closing
+// their channel, which hands the lock over without releasing it.
+type entry struct {
+	waiters []chan struct{}
+}
+
+// New creates an empty Locker.
+func New() *Locker {
+	return &Locker{keys: make(map[string]*entry)}
+}
+
+// Lock waits until key is free or ctx is done. On success it returns the
+// function that unlocks key; calling it more than once is harmless. When ctx
+// is done first, Lock returns ctx.Err() and does not hold the key.
+func (l *Locker) Lock(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	e, held := l.keys[key]
+	if !held {
+		l.keys[key] = &entry{}
+		l.mu.Unlock()
+		return l.unlocker(key), nil
+	}
+	wake := make(chan struct{})
+	e.waiters = append(e.waiters, wake)
+	l.mu.Unlock()
+
+	select {
+	case <-wake:
+		return l.unlocker(key), nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	for i, w := range e.waiters {
+		if w == wake {
+			e.waiters = append(e.waiters[:i], e.waiters[i+1:]...)
+			l.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	l.mu.Unlock()
+	// The lock was handed over while ctx was being cancelled; pass it on.
+	l.unlock(key)
+	return nil, ctx.Err()
+}
+
+// Len returns the number of keys currently held.
+func (l *Locker) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.keys)
+}
+
+func (l *Locker) unlocker(key string) func() {
+	var once sync.Once
+	return func() { once.Do(func() { l.unlock(key) }) }
+}
+
+func (l *Locker) unlock(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.keys[key]
+	if !ok {
+		return
+	}
+	if len(e.waiters) == 0 {
+		delete(l.keys, key)
+		return
+	}
+	next := e.waiters[0]
+	e.waiters = e.waiters[1:]
+	close(next)
+}
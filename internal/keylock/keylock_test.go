@@ -0,0 +1,123 @@
+package keylock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockServesWaitersInOrder(t *testing.T) {
+	l := New()
+	unlock, err := l.Lock(context.Background(), "k")
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			release, err := l.Lock(context.Background(), "k")
+			if err != nil {
+				t.Errorf("Lock %d: %v", i, err)
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			release()
+		}(i)
+		// 等待前一个 goroutine 进入队列，保证到达顺序
+		waitForWaiters(t, l, "k", i+1)
+	}
+	unlock()
+	wg.Wait()
+
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("waiters served out of order: %v", order)
+		}
+	}
+	if n := l.Len(); n != 0 {
+		t.Fatalf("locker kept %d keys", n)
+	}
+}
+
+func TestLockCancelledWaitDoesNotHoldKey(t *testing.T) {
+	l := New()
+	unlock, _ := l.Lock(context.Background(), "k")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(ctx, "k"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Lock with expired context = %v, want DeadlineExceeded", err)
+	}
+
+	unlock()
+	unlock() // 重复解锁无副作用
+	if n := l.Len(); n != 0 {
+		t.Fatalf("cancelled waiter left %d keys behind", n)
+	}
+	release, err := l.Lock(context.Background(), "k")
+	if err != nil {
+		t.Fatalf("key should be free after unlock: %v", err)
+	}
+	release()
+}
+
+func TestLockKeysAreIndependent(t *testing.T) {
+	l := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			unlock, err := l.Lock(context.Background(), fmt.Sprintf("k%d", i%4))
+			if err != nil {
+				t.Errorf("Lock: %v", err)
+				return
+			}
+			time.Sleep(time.Millisecond)
+			unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	a, _ := l.Lock(context.Background(), "a")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	b, err := l.Lock(ctx, "b")
+	if err != nil {
+		t.Fatalf("holding one key must not block another: %v", err)
+	}
+	a()
+	b()
+	if n := l.Len(); n != 0 {
+		t.Fatalf("locker kept %d keys", n)
+	}
+}
+
+func waitForWaiters(t *testing.T, l *Locker, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		l.mu.Lock()
+		e := l.keys[key]
+		got := 0
+		if e != nil {
+			got = len(e.waiters)
+		}
+		l.mu.Unlock()
+		if got >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d waiters on %q", n, key)
+}
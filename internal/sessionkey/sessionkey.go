@@ -0,0 +1,68 @@
+// Package sessionkey holds the escaping of session key components, shared by
+// the agent package (which builds and parses keys) and the session package
+// (which stores sessions under them).
+//
+// A key is made of components joined by Sep. Inside a component "%" is
+// written as "%25" and ":" as "%3A", so components may contain the separator.
+package sessionkey
+
+import "strings"
+
+// Sep separates the components of a session key.
+const Sep = ":"
+
+var partEscaper = strings.NewReplacer("%", "%25", Sep, "%3A")
+
+// EscapePart trims s and escapes it for use as one key component.
+func EscapePart(s string) string {
+	return partEscaper.Replace(strings.TrimSpace(s))
+}
+
+// UnescapePart trims s and decodes "%25" and "%3A" (or "%3a"); any other "%"
+// is kept literally.
+func UnescapePart(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			switch s[i+1 : i+3] {
+			case "25":
+				b.WriteByte('%')
+				i += 2
+				continue
+			case "3A", "3a":
+				b.WriteString(Sep)
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// SplitParts splits key at every separator and trims and unescapes each
+// component. Empty components are kept in place; an empty key gives nil.
+func SplitParts(key string) []string {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil
+	}
+	parts := strings.Split(key, Sep)
+	for i, part := range parts {
+		parts[i] = UnescapePart(part)
+	}
+	return parts
+}
+
+// Legacy returns key with every component unescaped, i.e. the form keys had
+// before components were escaped. It equals the trimmed key when nothing was
+// escaped.
+func Legacy(key string) string {
+	return strings.Join(SplitParts(key), Sep)
+}
@@ -0,0 +1,41 @@
+package sessionkey
+
+import "testing"
+
+func TestEscapePartRoundTrip(t *testing.T) {
+	for _, s := range []string{"", "chat", "group:42", "100%", "%3A", "a:%:b", "%zz"} {
+		escaped := EscapePart(s)
+		if got := UnescapePart(escaped); got != s {
+			t.Errorf("UnescapePart(EscapePart(%q)) = %q", s, got)
+		}
+		if len(SplitParts(escaped)) > 1 {
+			t.Errorf("EscapePart(%q) = %q still contains the separator", s, escaped)
+		}
+	}
+}
+
+func TestUnescapePartKeepsUnknownEscapes(t *testing.T) {
+	for in, want := range map[string]string{
+		" a%3ab ": "a:b",
+		"a%2":     "a%2",
+		"a%41":    "a%41",
+		"%2525":   "%25",
+	} {
+		if got := UnescapePart(in); got != want {
+			t.Errorf("UnescapePart(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLegacy(t *testing.T) {
+	for key, want := range map[string]string{
+		"telegram:bot:group%3A42%25": "telegram:bot:group:42%",
+		" tg:bot:chat ":              "tg:bot:chat",
+		"tg::chat%3a1":               "tg::chat:1",
+		"":                           "",
+	} {
+		if got := Legacy(key); got != want {
+			t.Errorf("Legacy(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
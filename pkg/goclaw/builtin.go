@@ -0,0 +1,114 @@
+package goclaw
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	agentruntime "github.com/smallnest/goclaw/agent/runtime"
+	"github.com/smallnest/goclaw/agent/tools"
+	"github.com/smallnest/goclaw/config"
+	"github.com/smallnest/goclaw/internal/logger"
+	"go.uber.org/zap"
+)
+
+// registerBuiltinTools mirrors the tool set the CLI entrypoints register.
+func (c *Client) registerBuiltinTools() {
+	cfg := c.cfg
+
+	invalidateRuntime := tools.RuntimeInvalidator(func(ctx context.Context, agentID string) error {
+		if c.mainRuntime == nil {
+			return fmt.Errorf("main runtime is not initialized")
+		}
+		return c.mainRuntime.Invalidate(strings.TrimSpace(agentID))
+	})
+
+	var builtin []tools.Tool
+	if c.searchMgr != nil {
+		builtin = append(builtin, tools.NewMemoryTool(c.searchMgr), tools.NewMemoryAddTool(c.searchMgr))
+	}
+
+	builtin = append(builtin, tools.NewFileSystemTool(cfg.Tools.FileSystem.AllowedPaths, cfg.Tools.FileSystem.DeniedPaths, c.workspace).GetTools()...)
+
+	shellTool := tools.NewShellTool(
+		cfg.Tools.Shell.Enabled,
+		cfg.Tools.Shell.AllowedCmds,
+		cfg.Tools.Shell.DeniedCmds,
+		cfg.Tools.Shell.Timeout,
+		cfg.Tools.Shell.WorkingDir,
+		cfg.Tools.Shell.Sandbox,
+	)
+	builtin = append(builtin, shellTool.GetTools()...)
+
+	webTool := tools.NewWebTool(
+		cfg.Tools.Web.SearchAPIKey,
+		cfg.Tools.Web.SearchEngine,
+		cfg.Tools.Web.Timeout,
+	)
+	builtin = append(builtin, webTool.GetTools()...)
+
+	browserTimeout := 30
+	if cfg.Tools.Browser.Timeout > 0 {
+		browserTimeout = cfg.Tools.Browser.Timeout
+	}
+	builtin = append(builtin, tools.NewSmartSearch(webTool, true, browserTimeout).GetTool())
+
+	if cfg.Tools.Browser.Enabled {
+		builtin = append(builtin, tools.NewBrowserTool(cfg.Tools.Browser.Headless, cfg.Tools.Browser.Timeout).GetTools()...)
+	}
+
+	skillsRoleDir := "skills"
+	if sub := cfg.Agents.Defaults.Subagents; sub != nil {
+		if strings.TrimSpace(sub.SkillsRoleDir) != "" {
+			skillsRoleDir = strings.TrimSpace(sub.SkillsRoleDir)
+		}
+	}
+	builtin = append(builtin,
+		tools.NewMCPListTool(c.workspace, skillsRoleDir),
+		tools.NewMCPPutServerTool(c.workspace, skillsRoleDir, invalidateRuntime),
+		tools.NewMCPDeleteServerTool(c.workspace, skillsRoleDir, invalidateRuntime),
+		tools.NewMCPSetEnabledTool(c.workspace, skillsRoleDir, invalidateRuntime),
+		tools.NewRuntimeReloadTool(invalidateRuntime),
+	)
+
+	for _, tool := range builtin {
+		if tool == nil {
+			continue
+		}
+		if err := c.toolReg.RegisterExisting(tool); err != nil {
+			logger.Warn("Failed to register builtin tool",
+				zap.String("tool", tool.Name()),
+				zap.Error(err))
+		}
+	}
+}
+
+func buildSubagentRuntime(cfg *config.Config) agentruntime.SubagentRuntime {
+	subagentCfg := cfg.Agents.Defaults.Subagents
+	roleLimits := map[string]int{}
+	defaultMaxConcurrent := 8
+	subagentModel := "claude-sonnet-4-5"
+	if subagentCfg != nil {
+		if subagentCfg.MaxConcurrent > 0 {
+			defaultMaxConcurrent = subagentCfg.MaxConcurrent
+		}
+		for role, limit := range subagentCfg.RoleMaxConcurrent {
+			if limit > 0 {
+				roleLimits[role] = limit
+			}
+		}
+		if strings.TrimSpace(subagentCfg.Model) != "" {
+			subagentModel = strings.TrimSpace(subagentCfg.Model)
+		}
+	}
+
+	return agentruntime.NewAgentsdkRuntime(agentruntime.AgentsdkRuntimeOptions{
+		Pool:             agentruntime.NewSimpleRolePool(defaultMaxConcurrent, roleLimits),
+		AnthropicAPIKey:  strings.TrimSpace(cfg.Providers.Anthropic.APIKey),
+		AnthropicBaseURL: strings.TrimSpace(cfg.Providers.Anthropic.BaseURL),
+		ModelName:        subagentModel,
+		MaxTokens:        cfg.Agents.Defaults.MaxTokens,
+		Temperature:      cfg.Agents.Defaults.Temperature,
+		MaxIterations:    cfg.Agents.Defaults.MaxIterations,
+	})
+}
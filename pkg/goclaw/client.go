@@ -0,0 +1,385 @@
+package goclaw
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smallnest/goclaw/agent"
+	tasksdk "github.com/smallnest/goclaw/agent/tasksdk"
+	"github.com/smallnest/goclaw/agent/tools"
+	"github.com/smallnest/goclaw/bus"
+	"github.com/smallnest/goclaw/config"
+	"github.com/smallnest/goclaw/internal/keylock"
+	"github.com/smallnest/goclaw/internal/logger"
+	"github.com/smallnest/goclaw/memory"
+	"github.com/smallnest/goclaw/session"
+	"go.uber.org/zap"
+)
+
+// Config is the goclaw configuration accepted by New.
+type Config = config.Config
+
+// Tool is the interface custom tools implement to be registered on a Client.
+// With cfg.ReadOnly enabled, custom tools are treated as mutating unless they
+// also implement Mutating() bool and return false.
+type Tool = tools.Tool
+
+// NewTool builds a Tool from a handler function.
+func NewTool(name, description string, parameters map[string]interface{}, fn func(ctx context.Context, params map[string]interface{}) (string, error)) Tool {
+	return tools.NewBaseTool(name, description, parameters, fn)
+}
+
+// Media is an optional attachment sent alongside a prompt.
+type Media struct {
+	Type     string // image, document, ...
+	URL      string
+	Base64   string
+	MimeType string
+}
+
+// Response is the result of a single agent turn.
+type Response struct {
+	SessionKey string
+	Output     string
+}
+
+// Client is an embedded goclaw agent runtime.
+// It is safe for concurrent use. Turns on different sessions run concurrently;
+// turns on the same session key run one at a time, in the order Send and
+// SendStream were called, and a waiting turn gives up when its ctx is done.
+type Client struct {
+	cfg       *config.Config
+	opts      options
+	workspace string
+
+	bus         *bus.MessageBus
+	sessionMgr  *session.Manager
+	toolReg     *agent.ToolRegistry
+	searchMgr   memory.MemorySearchManager
+	mainRuntime *agent.AgentSDKMainRuntime
+	manager     *agent.AgentManager
+	taskStore   *tasksdk.SQLiteStore
+	tracker     *tasksdk.Tracker
+	outSub      *bus.OutboundSubscription
+	turns       *keylock.Locker
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// New creates a Client from cfg. A nil cfg loads the default config the same
+// way the CLI does (./.goclaw/config.json, ./config.json, ~/.goclaw/config.json).
+func New(cfg *config.Config, opts ...Option) (*Client, error) {
+	if cfg == nil {
+		loaded, err := config.Load("")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		cfg = loaded
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	workspace := o.workspace
+	if workspace == "" {
+		resolved, err := config.GetWorkspacePath(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve workspace: %w", err)
+		}
+		workspace = resolved
+	}
+	if err := os.MkdirAll(workspace, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	sessionDir := o.sessionDir
+	if sessionDir == "" && o.cliSessions {
+		homeDir, err := config.ResolveUserHomeDir()
+		if err != nil {
+			homeDir = ""
+		}
+		sessionDir = filepath.Join(homeDir, ".goclaw", "sessions")
+	}
+	if sessionDir == "" {
+		sessionDir = filepath.Join(workspace, "sessions")
+	}
+	sessionMgr, err := session.NewManager(sessionDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session manager: %w", err)
+	}
+
+	c := &Client{
+		cfg:        cfg,
+		opts:       o,
+		workspace:  workspace,
+		bus:        bus.NewMessageBus(100),
+		sessionMgr: sessionMgr,
+		toolReg:    agent.NewToolRegistry(),
+		turns:      keylock.New(),
+	}
+
+	if err := c.setup(); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) setup() error {
+	cfg := c.cfg
+
+	searchMgr, err := memory.GetMemorySearchManager(cfg.Memory, c.workspace)
+	if err != nil {
+		logger.Warn("Failed to create memory search manager", zap.Error(err))
+	}
+	c.searchMgr = searchMgr
+
+	contextCfg := cfg.Memory.Memsearch.Context
+	if contextCfg.Limit == 0 {
+		contextCfg.Limit = 6
+	}
+	memoryStore := agent.NewMemoryStore(c.workspace, searchMgr, contextCfg.Query, contextCfg.Limit, contextCfg.Enabled)
+	if !cfg.ReadOnly.Enabled {
+		if err := memoryStore.EnsureBootstrapFiles(); err != nil {
+			logger.Warn("Failed to create bootstrap files", zap.Error(err))
+		}
+	}
+
+	contextBuilder := agent.NewContextBuilder(memoryStore, c.workspace)
+	contextBuilder.SetToolRegistry(c.toolReg)
+	agent.ApplyReadOnly(cfg, c.toolReg, contextBuilder)
+
+	if c.opts.builtinTools {
+		c.registerBuiltinTools()
+	}
+
+	c.taskStore, err = tasksdk.NewSQLiteStore(filepath.Join(c.workspace, "data", "agentsdk_tasks.db"))
+	if err != nil {
+		return fmt.Errorf("failed to initialize agentsdk task store: %w", err)
+	}
+	c.tracker, err = tasksdk.NewTracker(c.taskStore, filepath.Join(c.workspace, "data", "subagent_task_tracker.db"))
+	if err != nil {
+		return fmt.Errorf("failed to initialize subagent task tracker: %w", err)
+	}
+
+	c.mainRuntime, err = agent.NewAgentSDKMainRuntime(agent.AgentSDKMainRuntimeOptions{
+		Config:           cfg,
+		Tools:            c.toolReg,
+		DefaultWorkspace: c.workspace,
+		TaskStore:        c.taskStore,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create main runtime: %w", err)
+	}
+
+	c.manager = agent.NewAgentManager(&agent.NewAgentManagerConfig{
+		Bus:             c.bus,
+		SessionMgr:      c.sessionMgr,
+		Tools:           c.toolReg,
+		DataDir:         c.workspace,
+		Workspace:       c.workspace,
+		SubagentRuntime: buildSubagentRuntime(cfg),
+		MainRuntime:     c.mainRuntime,
+		TaskStore:       c.tracker,
+	})
+	if err := c.manager.SetupFromConfig(cfg, contextBuilder); err != nil {
+		return fmt.Errorf("failed to setup agent manager: %w", err)
+	}
+
+	// Drain outbound messages so announce flows never block on a full bus.
+	c.outSub = c.bus.SubscribeOutbound()
+	c.wg.Add(1)
+	go c.forwardOutbound(c.outSub)
+
+	return nil
+}
+
+func (c *Client) forwardOutbound(sub *bus.OutboundSubscription) {
+	defer c.wg.Done()
+	for msg := range sub.Channel {
+		if msg == nil || c.opts.onMessage == nil {
+			continue
+		}
+		c.opts.onMessage(toMessage(msg))
+	}
+}
+
+// NewSession opens (or creates) the session identified by key and returns
+// its canonical key. An empty key creates a fresh session on the client's
+// channel and account.
+func (c *Client) NewSession(key string) (string, error) {
+	if err := c.checkOpen(); err != nil {
+		return "", err
+	}
+
+	sessionKey, _ := agent.ResolveSessionKey(agent.SessionKeyOptions{
+		Explicit:       key,
+		Channel:        c.opts.channel,
+		AccountID:      c.opts.accountID,
+		ChatID:         "default",
+		FreshOnDefault: true,
+		Now:            time.Now(),
+	})
+
+	sess, err := c.sessionMgr.GetOrCreate(sessionKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	if err := c.sessionMgr.Save(sess); err != nil {
+		return "", fmt.Errorf("failed to save session: %w", err)
+	}
+	return sessionKey, nil
+}
+
+// Send runs one agent turn on sessionKey and returns the final output.
+func (c *Client) Send(ctx context.Context, sessionKey, prompt string, media ...Media) (*Response, error) {
+	return c.SendStream(ctx, sessionKey, prompt, nil, media...)
+}
+
+// SendStream runs one agent turn on sessionKey, invoking onDelta with each
+// text delta as it streams in. The returned Response carries the full output.
+func (c *Client) SendStream(ctx context.Context, sessionKey, prompt string, onDelta func(string), media ...Media) (*Response, error) {
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+	sessionKey = strings.TrimSpace(sessionKey)
+	if sessionKey == "" {
+		return nil, fmt.Errorf("session key is required")
+	}
+
+	unlock, err := c.turns.Lock(ctx, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	ref := agent.ParseSessionRef(sessionKey).WithDefaults("sdk")
+	msg := &bus.InboundMessage{
+		Channel:   ref.Channel,
+		AccountID: ref.AccountID,
+		ChatID:    ref.ChatID,
+		Content:   prompt,
+		Timestamp: time.Now(),
+	}
+	for _, m := range media {
+		msg.Media = append(msg.Media, bus.Media{
+			Type:     m.Type,
+			URL:      m.URL,
+			Base64:   m.Base64,
+			MimeType: m.MimeType,
+		})
+	}
+
+	output, err := c.manager.RunStream(ctx, msg, agent.StreamRunOptions{
+		ExplicitSessionKey: sessionKey,
+		AgentID:            c.opts.agentID,
+		OnEvent: func(evt agent.StreamEvent) {
+			if onDelta == nil {
+				return
+			}
+			if delta := agent.ExtractTextDelta(evt); delta != "" {
+				onDelta(delta)
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Response{SessionKey: sessionKey, Output: output}, nil
+}
+
+// RegisterTool adds a custom tool. Cached runtimes are invalidated so the tool
+// is visible to the model from the next turn on.
+func (c *Client) RegisterTool(tool Tool) error {
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+	if tool == nil {
+		return fmt.Errorf("tool is nil")
+	}
+	if err := c.toolReg.RegisterExisting(tool); err != nil {
+		return err
+	}
+	c.invalidateRuntimes()
+	return nil
+}
+
+// ListSessions returns the keys of all persisted sessions in the session dir.
+func (c *Client) ListSessions() ([]string, error) {
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+	return c.sessionMgr.List()
+}
+
+// Close stops the runtime and releases task stores and the memory backend.
+// It is safe to call more than once.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	var firstErr error
+	keep := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if c.manager != nil {
+		keep(c.manager.Stop())
+	} else if c.mainRuntime != nil {
+		keep(c.mainRuntime.Close())
+	}
+	if c.tracker != nil {
+		keep(c.tracker.Close())
+	}
+	if c.taskStore != nil {
+		keep(c.taskStore.Close())
+	}
+	if c.searchMgr != nil {
+		keep(c.searchMgr.Close())
+	}
+	if c.bus != nil {
+		keep(c.bus.Close())
+	}
+	c.wg.Wait()
+	return firstErr
+}
+
+func (c *Client) checkOpen() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return fmt.Errorf("goclaw client is closed")
+	}
+	return nil
+}
+
+func (c *Client) invalidateRuntimes() {
+	if c.mainRuntime == nil || c.manager == nil {
+		return
+	}
+	for _, agentID := range c.manager.ListAgents() {
+		if err := c.mainRuntime.Invalidate(agentID); err != nil {
+			logger.Warn("Failed to invalidate runtime",
+				zap.String("agent_id", agentID),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package goclaw
+
+import (
+	"context"
+	"errors"
+	"go/build"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smallnest/goclaw/config"
+)
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Workspace.Path = t.TempDir()
+	client, err := New(cfg, WithSessionDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestNewSessionIsListedAndReusable(t *testing.T) {
+	client := newTestClient(t)
+
+	key, err := client.NewSession("")
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	if !strings.HasPrefix(key, "sdk:default:") {
+		t.Fatalf("unexpected fresh key %q", key)
+	}
+
+	again, err := client.NewSession(key)
+	if err != nil {
+		t.Fatalf("NewSession(existing): %v", err)
+	}
+	if again != key {
+		t.Fatalf("expected explicit key to be kept, got %q", again)
+	}
+
+	keys, err := client.ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != key {
+		t.Fatalf("expected [%s], got %v", key, keys)
+	}
+}
+
+func TestDefaultSessionDirIsInsideWorkspace(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Workspace.Path = t.TempDir()
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	key, err := client.NewSession("")
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	want := filepath.Join(cfg.Workspace.Path, "sessions") + string(filepath.Separator)
+	if path := client.sessionMgr.SessionPath(key); !strings.HasPrefix(path, want) {
+		t.Fatalf("session stored at %s, want it under %s", path, want)
+	}
+}
+
+func TestRegisterToolRejectsDuplicates(t *testing.T) {
+	client := newTestClient(t)
+
+	echo := NewTool("echo", "echo input", map[string]interface{}{"type": "object"},
+		func(ctx context.Context, params map[string]interface{}) (string, error) { return "ok", nil })
+	if err := client.RegisterTool(echo); err != nil {
+		t.Fatalf("RegisterTool: %v", err)
+	}
+	if err := client.RegisterTool(echo); err == nil {
+		t.Fatalf("expected duplicate registration to fail")
+	}
+	if err := client.RegisterTool(nil); err == nil {
+		t.Fatalf("expected nil tool to be rejected")
+	}
+}
+
+func TestClosedClientRejectsCalls(t *testing.T) {
+	client := newTestClient(t)
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if _, err := client.NewSession(""); err == nil {
+		t.Fatalf("expected NewSession on closed client to fail")
+	}
+	if _, err := client.Send(context.Background(), "sdk:default:x", "hi"); err == nil {
+		t.Fatalf("expected Send on closed client to fail")
+	}
+}
+
+func TestSendRequiresSessionKey(t *testing.T) {
+	client := newTestClient(t)
+	if _, err := client.Send(context.Background(), "  ", "hi"); err == nil {
+		t.Fatalf("expected empty session key to be rejected")
+	}
+}
+
+func TestSendWaitsForTheRunningTurnOfItsSession(t *testing.T) {
+	client := newTestClient(t)
+	// 模拟同一会话上正在进行的一轮
+	unlock, err := client.turns.Lock(context.Background(), "sdk:embed:chat1")
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Send(ctx, " sdk:embed:chat1 ", "hi"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send on a busy session = %v, want it to wait until ctx is done", err)
+	}
+}
+
+func TestSDKDoesNotDependOnCLIOrChannels(t *testing.T) {
+	forbidden := []string{
+		"github.com/spf13/cobra",
+		"github.com/chzyer/readline",
+		"github.com/smallnest/goclaw/channels",
+		"github.com/smallnest/goclaw/cli",
+	}
+
+	seen := map[string]bool{}
+	var walk func(path, srcDir string)
+	walk = func(path, srcDir string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		for _, f := range forbidden {
+			if path == f || strings.HasPrefix(path, f+"/") {
+				t.Errorf("pkg/goclaw transitively imports %s", path)
+				return
+			}
+		}
+		if !strings.HasPrefix(path, "github.com/smallnest/goclaw") {
+			return
+		}
+		pkg, err := build.Import(path, srcDir, 0)
+		if err != nil {
+			t.Fatalf("import %s: %v", path, err)
+		}
+		for _, imp := range pkg.Imports {
+			walk(imp, pkg.Dir)
+		}
+	}
+	walk("github.com/smallnest/goclaw/pkg/goclaw", ".")
+}
@@ -0,0 +1,27 @@
+// Package goclaw is the embedding SDK for goclaw agents.
+//
+// A Client wires the same runtime the CLI uses (session manager, tool registry,
+// agentsdk main runtime, subagent support) without pulling in cobra, readline
+// or any channel adapter. The message bus and inbound dispatcher stay private
+// to the Client; callers only deal with session keys, prompts and tools.
+//
+//	client, err := goclaw.New(cfg, goclaw.WithBuiltinTools(true))
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	key, _ := client.NewSession("")
+//	resp, err := client.Send(ctx, key, "hello")
+//
+// # Compatibility
+//
+// This package follows semantic versioning independently of the CLI, tracked
+// by SDKVersion. Exported identifiers in pkg/goclaw are only removed or changed
+// incompatibly on a major version bump; additions bump the minor version.
+// Types re-exported from internal packages (Config, Tool) are covered by the
+// same promise as long as they are reached through this package.
+package goclaw
+
+// SDKVersion is the semantic version of the embedding API in this package.
+const SDKVersion = "0.1.0"
@@ -0,0 +1,108 @@
+package goclaw
+
+import (
+	"strings"
+
+	"github.com/smallnest/goclaw/bus"
+)
+
+// Option customizes a Client.
+type Option func(*options)
+
+// Message is an outbound message produced outside of a direct Send call,
+// for example a subagent announcing its result back to the requester session.
+type Message struct {
+	Channel  string
+	ChatID   string
+	Content  string
+	Metadata map[string]interface{}
+}
+
+type options struct {
+	workspace    string
+	sessionDir   string
+	cliSessions  bool
+	agentID      string
+	channel      string
+	accountID    string
+	builtinTools bool
+	onMessage    func(Message)
+}
+
+func defaultOptions() options {
+	return options{
+		channel:   "sdk",
+		accountID: "default",
+	}
+}
+
+// WithWorkspace overrides the workspace directory from config.
+func WithWorkspace(dir string) Option {
+	return func(o *options) {
+		o.workspace = strings.TrimSpace(dir)
+	}
+}
+
+// WithSessionDir overrides where session transcripts are stored.
+// Defaults to <workspace>/sessions, so the Client only sees its own sessions.
+func WithSessionDir(dir string) Option {
+	return func(o *options) {
+		o.sessionDir = strings.TrimSpace(dir)
+	}
+}
+
+// WithCLISessions stores sessions in ~/.goclaw/sessions, shared with the CLI,
+// TUI and gateway. ListSessions then returns their sessions too.
+func WithCLISessions() Option {
+	return func(o *options) {
+		o.cliSessions = true
+	}
+}
+
+// WithAgentID routes every Send to the given configured agent instead of
+// resolving it from bindings.
+func WithAgentID(agentID string) Option {
+	return func(o *options) {
+		o.agentID = strings.TrimSpace(agentID)
+	}
+}
+
+// WithChannel sets the channel and account used for sessions created by
+// NewSession. Defaults to "sdk" and "default".
+func WithChannel(channel, accountID string) Option {
+	return func(o *options) {
+		if c := strings.TrimSpace(channel); c != "" {
+			o.channel = c
+		}
+		if a := strings.TrimSpace(accountID); a != "" {
+			o.accountID = a
+		}
+	}
+}
+
+// WithBuiltinTools registers the built-in tool set (filesystem, shell, web,
+// smart_search, memory, MCP management and, when enabled in config, browser).
+// Without it the client starts with sessions_spawn only and callers add their
+// own tools via RegisterTool.
+func WithBuiltinTools(enabled bool) Option {
+	return func(o *options) {
+		o.builtinTools = enabled
+	}
+}
+
+// WithMessageHandler receives messages the runtime publishes on its own,
+// such as subagent announcements. Messages are dropped when no handler is set.
+func WithMessageHandler(fn func(Message)) Option {
+	return func(o *options) {
+		o.onMessage = fn
+	}
+}
+
+func toMessage(msg *bus.OutboundMessage) Message {
+	return Message{
+		Channel:  msg.Channel,
+		ChatID:   msg.ChatID,
+		Content:  msg.Content,
+		Metadata: msg.Metadata,
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/smallnest/goclaw/internal/sessionkey"
 )
 
 // Media 媒体文件
@@ -117,6 +119,9 @@ func (m *Manager) GetOrCreate(key string) (*Session, error) {
 
 	// 尝试从磁盘加载
 	session, err := m.load(key)
+	if os.IsNotExist(err) {
+		session, err = m.migrateUnescapedKey(key)
+	}
 	if err != nil {
 		if !os.IsNotExist(err) {
 			return nil, err
@@ -290,6 +295,30 @@ func (m *Manager) readKeyFromSessionFile(path string) (string, bool) {
 	return key, true
 }
 
+// migrateUnescapedKey loads a session saved before session key components
+// were escaped, when chat IDs containing ":" or "%" were stored raw, and
+// moves it to key. It returns an os.IsNotExist error when there is none.
+func (m *Manager) migrateUnescapedKey(key string) (*Session, error) {
+	oldKey := sessionkey.Legacy(key)
+	if oldKey == key {
+		return nil, os.ErrNotExist
+	}
+	session, err := m.load(oldKey)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Key = key
+	if err := m.Save(session); err != nil {
+		// 保存失败时保留旧文件，避免丢失历史
+		return session, nil
+	}
+	for _, filePath := range []string{m.sessionPath(oldKey), m.legacySessionPath(oldKey)} {
+		_ = os.Remove(filePath)
+	}
+	return session, nil
+}
+
 // load 从磁盘加载会话
 func (m *Manager) load(key string) (*Session, error) {
 	filePath := m.sessionPath(key)
@@ -208,3 +208,51 @@ func TestManagerSaveShouldNotCollideWhenKeysSanitizeToSameFilename(t *testing.T)
 		t.Fatalf("expected session B content %q, got %q", "message-from-B", loadedB.Messages[0].Content)
 	}
 }
+
+func TestManagerGetOrCreateMigratesUnescapedKey(t *testing.T) {
+	baseDir := t.TempDir()
+	manager, err := NewManager(baseDir)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	// 旧版本按原样保存含 ":" 和 "%" 的 chat ID
+	oldKey := "telegram:bot1:group:42%"
+	old, err := manager.GetOrCreate(oldKey)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	old.AddMessage(Message{Role: "user", Content: "hello", Timestamp: time.Now()})
+	if err := manager.Save(old); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	upgraded, err := NewManager(baseDir)
+	if err != nil {
+		t.Fatalf("failed to create upgraded manager: %v", err)
+	}
+	newKey := "telegram:bot1:group%3A42%25"
+	s, err := upgraded.GetOrCreate(newKey)
+	if err != nil {
+		t.Fatalf("GetOrCreate(%q): %v", newKey, err)
+	}
+	if s.Key != newKey || len(s.Messages) != 1 || s.Messages[0].Content != "hello" {
+		t.Fatalf("session not migrated: key %q, messages %+v", s.Key, s.Messages)
+	}
+
+	keys, err := upgraded.List()
+	if err != nil {
+		t.Fatalf("failed to list sessions: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != newKey {
+		t.Fatalf("expected only the migrated key, got %v", keys)
+	}
+	if _, err := os.Stat(upgraded.SessionPath(oldKey)); !os.IsNotExist(err) {
+		t.Fatalf("old session file should be removed after migration, stat err = %v", err)
+	}
+
+	fresh, err := upgraded.GetOrCreate("telegram:bot1:other%3Achat")
+	if err != nil || len(fresh.Messages) != 0 {
+		t.Fatalf("keys without an old session must start empty: %+v, %v", fresh, err)
+	}
+}